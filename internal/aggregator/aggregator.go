@@ -4,32 +4,128 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ObiAU/hfnewsaggregator/internal/ai"
+	"github.com/ObiAU/hfnewsaggregator/internal/api"
 	"github.com/ObiAU/hfnewsaggregator/internal/cache"
 	"github.com/ObiAU/hfnewsaggregator/internal/config"
+	"github.com/ObiAU/hfnewsaggregator/internal/extract"
+	"github.com/ObiAU/hfnewsaggregator/internal/feedback"
+	"github.com/ObiAU/hfnewsaggregator/internal/grpcapi"
+	"github.com/ObiAU/hfnewsaggregator/internal/lang"
 	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/notify"
+	"github.com/ObiAU/hfnewsaggregator/internal/quota"
+	"github.com/ObiAU/hfnewsaggregator/internal/recorder"
 	"github.com/ObiAU/hfnewsaggregator/internal/sources"
+	"github.com/ObiAU/hfnewsaggregator/internal/stream"
 	"github.com/ObiAU/hfnewsaggregator/internal/telegram"
+	"github.com/ObiAU/hfnewsaggregator/internal/vectorstore"
+	"github.com/openai/openai-go/v2/option"
+	"google.golang.org/grpc"
 )
 
+const enrichConcurrency = 4
+
 type Aggregator struct {
 	config      *config.Config
 	cache       *cache.Cache
 	telegramBot *telegram.Bot
 	aiClient    *ai.OpenAIClient
+	vectors     *vectorstore.Store
+	quotas      *quota.Store
+	extractor   *extract.Extractor
 	sources     []models.NewsSource
+	notifiers   []*notify.Queue
+	email       *notify.Email
+	hub         *stream.Hub
+	health      map[string]*models.SourceHealth
 	server      *http.Server
+	grpcServer  *grpc.Server
 	mu          sync.RWMutex
 	running     bool
 	stopChan    chan struct{}
 }
 
 func New(cfg *config.Config, cacheLayer *cache.Cache, bot *telegram.Bot) *Aggregator {
-	aiClient := ai.NewOpenAIClient(cfg.OpenAIAPIKey)
+	recordMode, err := recorder.ParseMode(cfg.LLMRecordMode)
+	if err != nil {
+		log.Fatalf("Invalid LLM_RECORD_MODE: %v", err)
+	}
+
+	var aiOptions []option.RequestOption
+	if recordMode != recorder.ModeOff {
+		log.Printf("OpenAI requests in %s mode using fixtures in %s", recordMode, cfg.LLMFixturesDir)
+		aiOptions = append(aiOptions, option.WithHTTPClient(&http.Client{
+			Transport: recorder.NewTransport(recordMode, cfg.LLMFixturesDir, nil),
+		}))
+	}
+
+	aiClient := ai.NewOpenAIClient(cfg.OpenAIAPIKey, aiOptions...)
+	aiClient.SetTranslationTarget(cfg.TranslateTo)
+
+	var extractor *extract.Extractor
+	if cfg.EnrichFullText {
+		extractor = extract.New(cfg.EnrichUserAgent, int64(cfg.EnrichMaxBytes), cfg.EnrichTimeout)
+	}
+
+	vectors := vectorstore.New(cfg.CacheRetention)
+	bot.SetSemanticSearch(aiClient, vectors, cfg.SemanticThreshold)
+	bot.SetDigestSources(cacheLayer, aiClient)
+	bot.SetQuestionAnswerer(aiClient)
+
+	quotas, err := quota.Open(cfg.QuotaFile)
+	if err != nil {
+		log.Fatalf("Failed to load quotas: %v", err)
+	}
+	bot.SetQuotas(quotas)
+
+	votes, err := feedback.Open(cfg.FeedbackFile)
+	if err != nil {
+		log.Fatalf("Failed to load feedback: %v", err)
+	}
+	bot.SetFeedback(votes)
+
+	channels, err := notify.Load(cfg.NotifiersFile)
+	if err != nil {
+		log.Fatalf("Failed to load notifiers: %v", err)
+	}
+	notifiers := append([]notify.Notifier{bot}, channels...)
+
+	var email *notify.Email
+	if cfg.SMTPHost != "" {
+		email, err = notify.NewEmail(notify.EmailConfig{
+			Host:            cfg.SMTPHost,
+			Port:            cfg.SMTPPort,
+			Username:        cfg.SMTPUsername,
+			Password:        cfg.SMTPPassword,
+			From:            cfg.SMTPFrom,
+			BaseURL:         cfg.PublicBaseURL,
+			SubscribersFile: cfg.EmailSubscribers,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up email delivery: %v", err)
+		}
+		notifiers = append(notifiers, email)
+		bot.SetMailingList(email)
+	}
+
+	hub := stream.NewHub(cacheLayer)
+	notifiers = append(notifiers, hub)
+
+	for _, notifier := range notifiers[1:] {
+		log.Printf("Delivering alerts to %s", notifier.Name())
+	}
+
+	queues := make([]*notify.Queue, len(notifiers))
+	for i, notifier := range notifiers {
+		queues[i] = notify.NewQueue(notifier, notify.QueueSize)
+	}
 
 	newsSources := []models.NewsSource{
 		sources.NewNewsAPIClient(cfg.NewsAPIKey),
@@ -37,14 +133,31 @@ func New(cfg *config.Config, cacheLayer *cache.Cache, bot *telegram.Bot) *Aggreg
 		sources.NewCryptoPanicClient(cfg.NewsAPIKey),
 	}
 
-	return &Aggregator{
+	health := make(map[string]*models.SourceHealth, len(newsSources))
+	for _, source := range newsSources {
+		health[source.GetName()] = &models.SourceHealth{Name: source.GetName()}
+	}
+
+	a := &Aggregator{
 		config:      cfg,
 		cache:       cacheLayer,
 		telegramBot: bot,
 		aiClient:    aiClient,
+		vectors:     vectors,
+		quotas:      quotas,
+		extractor:   extractor,
 		sources:     newsSources,
+		notifiers:   queues,
+		email:       email,
+		hub:         hub,
+		health:      health,
 		stopChan:    make(chan struct{}),
 	}
+
+	bot.SetAdmins(cfg.AdminUserIDs)
+	bot.SetAdminBackend(a)
+
+	return a
 }
 
 func (a *Aggregator) Run(ctx context.Context) error {
@@ -63,7 +176,15 @@ func (a *Aggregator) Run(ctx context.Context) error {
 	}
 
 	go a.startHTTPServer(ctx)
+	a.startGRPCServer()
 	go a.processNewsLoop(ctx)
+	go a.quotas.Run(ctx)
+	for _, queue := range a.notifiers {
+		go queue.Run(ctx)
+	}
+	if a.email != nil {
+		go a.email.Run(ctx)
+	}
 
 	<-ctx.Done()
 	return a.shutdown()
@@ -91,11 +212,16 @@ func (a *Aggregator) processNewsBatch(ctx context.Context) error {
 	var mu sync.Mutex
 
 	for _, source := range a.sources {
+		if a.sourcePaused(source.GetName()) {
+			continue
+		}
+
 		wg.Add(1)
 		go func(src models.NewsSource) {
 			defer wg.Done()
 
 			articles, err := src.FetchArticles(ctx, a.config.BatchSize)
+			a.recordFetch(src.GetName(), len(articles), err)
 			if err != nil {
 				log.Printf("Error fetching from %s: %v", src.GetName(), err)
 				return
@@ -116,21 +242,157 @@ func (a *Aggregator) processNewsBatch(ctx context.Context) error {
 
 	log.Printf("Processing %d new articles", len(newArticles))
 
+	a.enrichArticles(ctx, newArticles)
+
+	for i := range newArticles {
+		newArticles[i].Language = lang.Detect(newArticles[i].Title + " " + newArticles[i].Content + " " + newArticles[i].FullText)
+	}
+
 	categorized, err := a.aiClient.CategorizeArticles(ctx, newArticles)
 	if err != nil {
 		return fmt.Errorf("failed to categorize articles: %w", err)
 	}
 
+	a.embedArticles(ctx, categorized)
+
 	for _, catArticle := range categorized {
-		a.cache.AddArticle(catArticle.Article)
+		a.cache.AddCategorizedArticle(catArticle)
 		a.cache.MarkProcessed(catArticle.Article.Hash)
+	}
 
-		go a.telegramBot.SendAlert(ctx, catArticle)
+	// Articles whose results were rejected are cached uncategorized so they
+	// are not fetched and sent to the model again on the next interval.
+	for _, article := range newArticles {
+		if !a.cache.HasArticle(article.Hash) {
+			log.Printf("Skipping article %q: no valid categorization", article.Title)
+			a.cache.AddArticle(article)
+		}
 	}
 
+	a.notify(categorized)
+
 	return nil
 }
 
+// notify queues the batch for every notifier. Each one delivers from its
+// own goroutine in order, so a slow webhook only delays its own channel and
+// never the next batch.
+func (a *Aggregator) notify(articles []models.CategorizedArticle) {
+	for _, queue := range a.notifiers {
+		for _, article := range articles {
+			if !queue.Enqueue(article) {
+				log.Printf("Dropping %q for %s: its queue is full", article.Title, queue.Name())
+			}
+		}
+	}
+}
+
+func (a *Aggregator) recordFetch(name string, articles int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	health := a.health[name]
+	health.Fetches++
+	health.LastFetch = time.Now()
+	if err != nil {
+		health.Failures++
+		health.LastError = err.Error()
+		return
+	}
+	health.Articles += articles
+	health.LastSuccess = health.LastFetch
+	health.LastError = ""
+}
+
+func (a *Aggregator) sourcePaused(name string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.health[name].Paused
+}
+
+// SourceHealth reports fetch results for each source, in configured order.
+func (a *Aggregator) SourceHealth() []models.SourceHealth {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	health := make([]models.SourceHealth, 0, len(a.sources))
+	for _, source := range a.sources {
+		health = append(health, *a.health[source.GetName()])
+	}
+	return health
+}
+
+// SetSourcePaused stops or restarts fetching from a source; name matching
+// ignores case.
+func (a *Aggregator) SetSourcePaused(name string, paused bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for sourceName, health := range a.health {
+		if strings.EqualFold(sourceName, name) {
+			health.Paused = paused
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown source %q", name)
+}
+
+func (a *Aggregator) CacheStats() map[string]interface{} {
+	return a.cache.Stats()
+}
+
+func (a *Aggregator) AIUsage() models.AIUsage {
+	return a.aiClient.Usage()
+}
+
+func (a *Aggregator) enrichArticles(ctx context.Context, articles []models.Article) {
+	if a.extractor == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, enrichConcurrency)
+
+	for i := range articles {
+		wg.Add(1)
+		go func(article *models.Article) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			text, err := a.extractor.Extract(ctx, article.URL)
+			if article.Metadata == nil {
+				article.Metadata = make(map[string]string)
+			}
+			if err != nil {
+				article.Metadata["full_text_error"] = err.Error()
+				return
+			}
+
+			article.FullText = text
+		}(&articles[i])
+	}
+
+	wg.Wait()
+}
+
+func (a *Aggregator) embedArticles(ctx context.Context, articles []models.CategorizedArticle) {
+	if len(articles) == 0 {
+		return
+	}
+
+	vectors, err := a.aiClient.EmbedArticles(ctx, articles)
+	if err != nil {
+		log.Printf("Error embedding articles: %v", err)
+		return
+	}
+
+	for i, article := range articles {
+		a.vectors.Add(article.Hash, vectors[i])
+	}
+}
+
 func (a *Aggregator) filterNewArticles(articles []models.Article) []models.Article {
 	var newArticles []models.Article
 
@@ -148,6 +410,14 @@ func (a *Aggregator) startHTTPServer(ctx context.Context) {
 	mux.HandleFunc("/health", a.healthHandler)
 	mux.HandleFunc("/stats", a.statsHandler)
 	mux.HandleFunc("/webhook", a.telegramWebhookHandler)
+	if a.email != nil {
+		mux.HandleFunc("/unsubscribe", a.email.HandleUnsubscribe)
+	}
+	if len(a.config.APIKeys) > 0 {
+		mux.Handle("/api/v1/", api.New(a.config.APIKeys, a.cache, a.telegramBot, a.hub).Handler())
+	} else {
+		log.Println("REST API disabled: set API_KEYS to enable /api/v1/")
+	}
 
 	a.server = &http.Server{
 		Addr:    ":" + a.config.ServerPort,
@@ -161,6 +431,28 @@ func (a *Aggregator) startHTTPServer(ctx context.Context) {
 	}()
 }
 
+// startGRPCServer serves NewsService next to the HTTP server. Like the REST
+// API it is only enabled when API keys are configured.
+func (a *Aggregator) startGRPCServer() {
+	if len(a.config.APIKeys) == 0 {
+		log.Println("gRPC API disabled: set API_KEYS to enable it")
+		return
+	}
+
+	listener, err := net.Listen("tcp", ":"+a.config.GRPCPort)
+	if err != nil {
+		log.Printf("gRPC server error: %v", err)
+		return
+	}
+
+	a.grpcServer = grpcapi.New(a.config.APIKeys, a.cache, a.hub).GRPCServer()
+	go func() {
+		if err := a.grpcServer.Serve(listener); err != nil {
+			log.Printf("gRPC server error: %v", err)
+		}
+	}()
+}
+
 func (a *Aggregator) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -172,7 +464,7 @@ func (a *Aggregator) statsHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"cache_stats":%+v,"running":%t}`, stats, a.isRunning())
+	fmt.Fprintf(w, `{"cache_stats":%+v,"running":%t,"telegram_queue":%d}`, stats, a.isRunning(), a.telegramBot.QueueLength())
 }
 
 func (a *Aggregator) telegramWebhookHandler(w http.ResponseWriter, r *http.Request) {
@@ -193,6 +485,21 @@ func (a *Aggregator) isRunning() bool {
 func (a *Aggregator) shutdown() error {
 	log.Println("Shutting down aggregator...")
 
+	a.hub.Close()
+
+	if a.grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			a.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			a.grpcServer.Stop()
+		}
+	}
+
 	if a.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -202,6 +509,16 @@ func (a *Aggregator) shutdown() error {
 		}
 	}
 
+	if err := a.quotas.Flush(); err != nil {
+		log.Printf("Failed to save quotas: %v", err)
+	}
+	if a.email != nil {
+		if err := a.email.Flush(); err != nil {
+			log.Printf("Failed to save email subscribers: %v", err)
+		}
+	}
+
+	a.vectors.Close()
 	close(a.stopChan)
 	return nil
 }
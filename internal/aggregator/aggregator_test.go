@@ -0,0 +1,143 @@
+package aggregator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/config"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/telegram"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type fakeSource struct {
+	name     string
+	articles []models.Article
+}
+
+func (s fakeSource) FetchArticles(ctx context.Context, limit int) ([]models.Article, error) {
+	return s.articles, nil
+}
+
+func (s fakeSource) GetName() string {
+	return s.name
+}
+
+// newTestBot points the bot at a local server that accepts every call.
+func newTestBot(t *testing.T) *telegram.Bot {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true, "result": {}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	api := &tgbotapi.BotAPI{Token: "test", Client: server.Client(), Buffer: 100}
+	api.SetAPIEndpoint(server.URL + "/bot%s/%s")
+	return telegram.NewBotWithAPI(api, "", "HTML")
+}
+
+func newTestAggregator(t *testing.T, articles []models.Article) *Aggregator {
+	cfg := &config.Config{
+		LLMRecordMode:     "replay",
+		LLMFixturesDir:    "../../testdata/llm",
+		QuotaFile:         filepath.Join(t.TempDir(), "quotas.json"),
+		BatchSize:         10,
+		CacheRetention:    time.Hour,
+		SemanticThreshold: 0.5,
+	}
+
+	cacheLayer := cache.New(time.Hour)
+	t.Cleanup(cacheLayer.Close)
+
+	a := New(cfg, cacheLayer, newTestBot(t))
+	source := fakeSource{name: "Fixtures", articles: articles}
+	a.sources = []models.NewsSource{source}
+	a.health = map[string]*models.SourceHealth{source.name: {Name: source.name}}
+	return a
+}
+
+func fixtureArticles() []models.Article {
+	return []models.Article{
+		{
+			ID:      "newsapi_https://example.com/markets/bitcoin-etf-inflows?utm_source=rss&utm_medium=feed",
+			Title:   "Bitcoin ETF inflows hit a record as the price climbs past $70,000",
+			Content: "Spot bitcoin exchange-traded funds took in more than $1 billion on Tuesday, the largest daily inflow since they launched.",
+			URL:     "https://example.com/markets/bitcoin-etf-inflows",
+			Source:  "NewsAPI",
+			Hash:    "3f1a9c0b7d2e4a51",
+		},
+		{
+			ID:      "treenews_8841",
+			Title:   "Central bank holds interest rates steady and signals cuts later this year",
+			Content: "Policymakers left the benchmark rate unchanged but said inflation was cooling faster than expected.",
+			URL:     "https://example.com/economy/rates-hold",
+			Source:  "TreeNews",
+			Hash:    "9b04e6d2c8a17f35",
+		},
+	}
+}
+
+func TestProcessNewsBatchReplay(t *testing.T) {
+	a := newTestAggregator(t, fixtureArticles())
+
+	if err := a.processNewsBatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"3f1a9c0b7d2e4a51": "cryptocurrency",
+		"9b04e6d2c8a17f35": "finance",
+	}
+	for hash, category := range want {
+		article, ok := a.cache.GetCategorizedArticle(hash)
+		if !ok {
+			t.Fatalf("article %s was not categorized", hash)
+		}
+		if article.Category != category {
+			t.Errorf("%s: category %q, want %q", hash, article.Category, category)
+		}
+		if article.Language != "en" {
+			t.Errorf("%s: language %q, want en", hash, article.Language)
+		}
+	}
+
+	if health := a.SourceHealth(); health[0].Fetches != 1 || health[0].Articles != 2 {
+		t.Errorf("source health not recorded: %+v", health[0])
+	}
+
+	// A second batch finds nothing new, so it must not need another fixture.
+	if err := a.processNewsBatch(context.Background()); err != nil {
+		t.Fatalf("second batch: %v", err)
+	}
+}
+
+func TestProcessNewsBatchCachesRejectedArticles(t *testing.T) {
+	rejected := models.Article{
+		ID:      "cryptopanic_20417",
+		Title:   "Top ten cat memes of the week",
+		Content: "Our favourite cat memes from around the internet.",
+		URL:     "https://example.com/fun/cat-memes",
+		Source:  "CryptoPanic",
+		Hash:    "c2d8e07a5b3f9164",
+	}
+	a := newTestAggregator(t, append(fixtureArticles(), rejected))
+
+	if err := a.processNewsBatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := a.cache.GetCategorizedArticle(rejected.Hash); ok {
+		t.Fatal("article with an invalid category was categorized")
+	}
+	if !a.cache.HasArticle(rejected.Hash) {
+		t.Fatal("rejected article was not cached, so it would be sent to the model again")
+	}
+	if _, ok := a.cache.GetCategorizedArticle("3f1a9c0b7d2e4a51"); !ok {
+		t.Error("valid article in the same batch was not categorized")
+	}
+}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/openai/openai-go/v2/option"
+)
+
+func TestAnswerQuestion(t *testing.T) {
+	var request struct {
+		ResponseFormat struct {
+			Type string `json:"type"`
+		} `json:"response_format"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"finish_reason":"stop",` +
+			`"message":{"role":"assistant","content":"{\"answer\": \" Inflows rose. \", \"sources\": [2, 2, 0, 9, 1]}"}}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient("test-key", option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	articles := []models.CategorizedArticle{{}, {}}
+	articles[0].Title = "Bitcoin ETF inflows"
+	articles[1].Title = "Central bank holds rates"
+
+	answer, err := client.AnswerQuestion(context.Background(), "why is bitcoin up?", articles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.ResponseFormat.Type != "json_object" {
+		t.Errorf("response_format type = %q, want json_object", request.ResponseFormat.Type)
+	}
+	if answer.Text != "Inflows rose." {
+		t.Errorf("text = %q", answer.Text)
+	}
+	if want := []int{1, 0}; !reflect.DeepEqual(answer.Citations, want) {
+		t.Errorf("citations = %v, want %v", answer.Citations, want)
+	}
+}
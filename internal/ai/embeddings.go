@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/openai/openai-go/v2"
+)
+
+const embeddingModel = openai.EmbeddingModelTextEmbedding3Small
+
+func (c *OpenAIClient) EmbedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	response, err := c.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model: embeddingModel,
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfArrayOfStrings: texts,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai embeddings request failed: %w", err)
+	}
+	c.usage.addEmbedding(response.Usage)
+
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(response.Data))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || int(item.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+
+	return vectors, nil
+}
+
+func (c *OpenAIClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	vectors, err := c.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (c *OpenAIClient) EmbedArticles(ctx context.Context, articles []models.CategorizedArticle) ([][]float64, error) {
+	texts := make([]string, len(articles))
+	for i, article := range articles {
+		texts[i] = embeddingText(article)
+	}
+	return c.EmbedTexts(ctx, texts)
+}
+
+func embeddingText(article models.CategorizedArticle) string {
+	text := article.Title
+	if article.Summary != "" {
+		text += "\n" + article.Summary
+	} else if article.Content != article.Title {
+		text += "\n" + article.Content
+	}
+	return text
+}
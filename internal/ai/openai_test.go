@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/recorder"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// Fixtures are shared with the aggregator tests; re-record them with
+// LLM_RECORD_MODE=record after changing a prompt.
+const fixturesDir = "../../testdata/llm"
+
+func replayClient() *OpenAIClient {
+	transport := recorder.NewTransport(recorder.ModeReplay, fixturesDir, nil)
+	return NewOpenAIClient("test-key",
+		option.WithHTTPClient(&http.Client{Transport: transport}),
+		option.WithMaxRetries(0),
+	)
+}
+
+func fixtureArticles() []models.Article {
+	return []models.Article{
+		{
+			ID:       "newsapi_https://example.com/markets/bitcoin-etf-inflows?utm_source=rss&utm_medium=feed",
+			Title:    "Bitcoin ETF inflows hit a record as the price climbs past $70,000",
+			Content:  "Spot bitcoin exchange-traded funds took in more than $1 billion on Tuesday, the largest daily inflow since they launched.",
+			URL:      "https://example.com/markets/bitcoin-etf-inflows",
+			Source:   "NewsAPI",
+			Hash:     "3f1a9c0b7d2e4a51",
+			Language: "en",
+		},
+		{
+			ID:       "treenews_8841",
+			Title:    "Central bank holds interest rates steady and signals cuts later this year",
+			Content:  "Policymakers left the benchmark rate unchanged but said inflation was cooling faster than expected.",
+			URL:      "https://example.com/economy/rates-hold",
+			Source:   "TreeNews",
+			Hash:     "9b04e6d2c8a17f35",
+			Language: "en",
+		},
+	}
+}
+
+func TestCategorizeArticlesReplay(t *testing.T) {
+	client := replayClient()
+
+	categorized, err := client.CategorizeArticles(context.Background(), fixtureArticles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct{ id, category, sentiment string }{
+		"3f1a9c0b7d2e4a51": {fixtureArticles()[0].ID, "cryptocurrency", "positive"},
+		"9b04e6d2c8a17f35": {fixtureArticles()[1].ID, "finance", "neutral"},
+	}
+	if len(categorized) != len(want) {
+		t.Fatalf("got %d categorized articles, want %d", len(categorized), len(want))
+	}
+	for _, article := range categorized {
+		w, ok := want[article.Hash]
+		if !ok {
+			t.Fatalf("unexpected article %q", article.Hash)
+		}
+		if article.ID != w.id {
+			t.Errorf("%s: ID %q, want %q", article.Hash, article.ID, w.id)
+		}
+		if article.Category != w.category || article.Sentiment != w.sentiment {
+			t.Errorf("%s: got %s/%s, want %s/%s", article.Hash, article.Category, article.Sentiment, w.category, w.sentiment)
+		}
+		if article.Summary == "" || article.Confidence <= 0 || article.ProcessedAt.IsZero() {
+			t.Errorf("%s: incomplete categorization %+v", article.Hash, article)
+		}
+	}
+
+	if usage := client.Usage(); usage.Requests != 1 || usage.PromptTokens == 0 {
+		t.Errorf("usage not tracked: %+v", usage)
+	}
+}
+
+func TestEmbedTextsReplay(t *testing.T) {
+	client := replayClient()
+
+	texts := []string{"Bitcoin ETF inflows", "Interest rate decision"}
+	vectors, err := client.EmbedTexts(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("got %d vectors, want %d", len(vectors), len(texts))
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			t.Errorf("vector %d is empty", i)
+		}
+	}
+}
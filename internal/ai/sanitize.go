@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+const (
+	maxPromptFieldLength = 2000
+	maxFullTextLength    = 6000
+	suspectedConfidence  = 0.5
+)
+
+var validCategories = []string{
+	"politics", "technology", "cryptocurrency", "finance", "sports",
+	"entertainment", "health", "science", "world", "business",
+}
+
+var validSentiments = []string{"positive", "negative", "neutral"}
+
+var injectionPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,30}\b(previous|prior|above|earlier|all|system)\b.{0,20}\b(instructions?|prompts?|rules?|messages?)`)},
+	{"role_override", regexp.MustCompile(`(?i)\b(you are now|act as|pretend to be|new instructions?|system prompt)\b`)},
+	{"chat_markup", regexp.MustCompile(`(?im)(<\|?(im_start|im_end|system|assistant)\|?>|^\s*(system|assistant)\s*:)`)},
+	{"output_steering", regexp.MustCompile(`(?i)\b(classify|categori[sz]e|label|mark|tag)\b.{0,30}\b(this|it|article)\b.{0,30}\b(as|with)\b`)},
+	{"confidence_steering", regexp.MustCompile(`(?i)\bconfidence\b.{0,20}\b(1(\.0+)?|100\s*%)|\b(1\.0+|100\s*%)\s*confidence\b`)},
+	{"delimiter_escape", regexp.MustCompile(`(?i)</?\s*(article|articles|field)\b`)},
+}
+
+// detectInjection returns the names of instruction-like patterns found in
+// the article's untrusted fields.
+func detectInjection(article models.Article) []string {
+	text := article.Title + "\n" + article.Content + "\n" + article.FullText
+
+	var matches []string
+	for _, p := range injectionPatterns {
+		if p.pattern.MatchString(text) {
+			matches = append(matches, p.name)
+		}
+	}
+
+	return matches
+}
+
+func flagSuspectedInjections(articles []models.Article) {
+	for i := range articles {
+		matches := detectInjection(articles[i])
+		if len(matches) == 0 {
+			continue
+		}
+
+		if articles[i].Metadata == nil {
+			articles[i].Metadata = make(map[string]string)
+		}
+		articles[i].Metadata["prompt_injection"] = "suspected"
+		articles[i].Metadata["prompt_injection_patterns"] = strings.Join(matches, ",")
+	}
+}
+
+func isSuspected(article models.Article) bool {
+	return article.Metadata["prompt_injection"] == "suspected"
+}
+
+// escapePromptField neutralises anything in untrusted text that could close
+// or forge the delimiters around it, and strips control characters.
+func escapePromptField(value string) string {
+	return escapePromptFieldLimit(value, maxPromptFieldLength)
+}
+
+func escapePromptFieldLimit(value string, limit int) string {
+	value = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+
+	if runes := []rune(value); len(runes) > limit {
+		value = string(runes[:limit]) + "…"
+	}
+
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(value)
+}
+
+// writeDelimitedArticle wraps an article's untrusted fields in tags. The
+// article is identified by its position in the batch rather than its own ID,
+// which comes from a URL and would not survive escaping intact.
+func writeDelimitedArticle(sb *strings.Builder, index int, article models.Article) {
+	if article.Language != "" {
+		sb.WriteString(fmt.Sprintf("<article id=\"%d\" lang=\"%s\">\n", index+1, escapePromptField(article.Language)))
+	} else {
+		sb.WriteString(fmt.Sprintf("<article id=\"%d\">\n", index+1))
+	}
+	sb.WriteString(fmt.Sprintf("<field name=\"title\">%s</field>\n", escapePromptField(article.Title)))
+	if article.FullText != "" {
+		sb.WriteString(fmt.Sprintf("<field name=\"content\">%s</field>\n", escapePromptFieldLimit(article.FullText, maxFullTextLength)))
+	} else {
+		sb.WriteString(fmt.Sprintf("<field name=\"content\">%s</field>\n", escapePromptField(article.Content)))
+	}
+	sb.WriteString(fmt.Sprintf("<field name=\"source\">%s</field>\n", escapePromptField(article.Source)))
+	sb.WriteString("</article>\n")
+}
+
+// validateCategorizations drops results that reference articles outside the
+// batch, repeat an ID, or carry values outside the allowed schema. Valid
+// results have index set to the article they describe.
+func validateCategorizations(articles []models.Article, results []CategorizedArticle) ([]CategorizedArticle, []error) {
+	seen := make(map[string]bool, len(results))
+	valid := make([]CategorizedArticle, 0, len(results))
+	var errs []error
+
+	for _, result := range results {
+		index, err := strconv.Atoi(strings.TrimSpace(result.ID))
+		switch {
+		case err != nil || index < 1 || index > len(articles):
+			errs = append(errs, fmt.Errorf("result references unknown article id %q", result.ID))
+			continue
+		case seen[result.ID]:
+			errs = append(errs, fmt.Errorf("duplicate result for article id %q", result.ID))
+			continue
+		case !contains(validCategories, result.Category):
+			errs = append(errs, fmt.Errorf("invalid category %q for article %q", result.Category, result.ID))
+			continue
+		case result.Confidence < 0 || result.Confidence > 1:
+			errs = append(errs, fmt.Errorf("confidence %.2f out of range for article %q", result.Confidence, result.ID))
+			continue
+		}
+
+		if result.Urgency < 0 || result.Urgency > 1 {
+			result.Urgency = 0
+		}
+
+		result.Category = strings.ToLower(result.Category)
+		result.Sentiment = strings.ToLower(result.Sentiment)
+		if !contains(validSentiments, result.Sentiment) {
+			result.Sentiment = "neutral"
+		}
+		result.Language = strings.ToLower(strings.TrimSpace(result.Language))
+		if len(result.Language) != 2 {
+			result.Language = ""
+		}
+		if len(result.Tags) > 5 {
+			result.Tags = result.Tags[:5]
+		}
+
+		seen[result.ID] = true
+		result.index = index - 1
+		valid = append(valid, result)
+	}
+
+	return valid, errs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/openai/openai-go/v2"
+)
+
+// Published gpt-4o-mini and text-embedding-3-small prices, in USD per
+// million tokens. Spend figures are estimates from these.
+const (
+	chatInputPricePerMillion  = 0.15
+	chatOutputPricePerMillion = 0.60
+	embeddingPricePerMillion  = 0.02
+)
+
+type usageTracker struct {
+	mu    sync.Mutex
+	usage models.AIUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{usage: models.AIUsage{Since: time.Now()}}
+}
+
+func (t *usageTracker) addChat(usage openai.CompletionUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.usage.Requests++
+	t.usage.PromptTokens += usage.PromptTokens
+	t.usage.CompletionTokens += usage.CompletionTokens
+	t.usage.EstimatedCostUSD += float64(usage.PromptTokens)*chatInputPricePerMillion/1e6 +
+		float64(usage.CompletionTokens)*chatOutputPricePerMillion/1e6
+}
+
+func (t *usageTracker) addEmbedding(usage openai.CreateEmbeddingResponseUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.usage.Requests++
+	t.usage.EmbeddingTokens += usage.TotalTokens
+	t.usage.EstimatedCostUSD += float64(usage.TotalTokens) * embeddingPricePerMillion / 1e6
+}
+
+// Usage reports token counts and estimated spend since the client started.
+func (c *OpenAIClient) Usage() models.AIUsage {
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	return c.usage.usage
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/telegram"
+)
+
+// alertRequest is the JSON body for creating or replacing an alert. An
+// alert has either a rule or filters; the delivery fields apply to both.
+type alertRequest struct {
+	ChatID         int64    `json:"chat_id"`
+	Name           string   `json:"name"`
+	Rule           string   `json:"rule,omitempty"`
+	Categories     []string `json:"categories,omitempty"`
+	Keywords       []string `json:"keywords,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Languages      []string `json:"languages,omitempty"`
+	Topic          string   `json:"topic,omitempty"`
+	TopicThreshold float64  `json:"topic_threshold,omitempty"`
+	MinConfidence  float64  `json:"min_confidence,omitempty"`
+	Sentiments     []string `json:"sentiments,omitempty"`
+	Sources        []string `json:"sources,omitempty"`
+	ExcludeSources []string `json:"exclude_sources,omitempty"`
+	MaxAge         string   `json:"max_age,omitempty"`
+	Delivery       string   `json:"delivery,omitempty"`
+	DigestAt       string   `json:"digest_at,omitempty"`
+	DigestOverview bool     `json:"digest_overview,omitempty"`
+	Enabled        *bool    `json:"enabled"`
+}
+
+// alertResponse is an alert as the API returns it: the request fields plus
+// read-only ones. Request bodies may carry the read-only fields too, which
+// are ignored, so a fetched alert can be edited and sent back with PUT.
+type alertResponse struct {
+	ID string `json:"id"`
+	alertRequest
+	UserID       int64     `json:"user_id"`
+	LastDigestAt time.Time `json:"last_digest_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (req alertRequest) spec() (telegram.AlertSpec, error) {
+	spec := telegram.AlertSpec{
+		ChatID:         req.ChatID,
+		Name:           req.Name,
+		Rule:           req.Rule,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		Categories:     req.Categories,
+		Keywords:       req.Keywords,
+		Tags:           req.Tags,
+		Languages:      req.Languages,
+		Topic:          req.Topic,
+		TopicThreshold: req.TopicThreshold,
+		MinConfidence:  req.MinConfidence,
+		Sentiments:     req.Sentiments,
+		Sources:        req.Sources,
+		ExcludeSources: req.ExcludeSources,
+		Delivery:       req.Delivery,
+		DigestAt:       req.DigestAt,
+		DigestOverview: req.DigestOverview,
+	}
+
+	if req.MaxAge != "" {
+		maxAge, err := time.ParseDuration(req.MaxAge)
+		if err != nil || maxAge <= 0 {
+			return spec, fmt.Errorf("%w: max_age must be a duration such as 30m or 2h, got %q", telegram.ErrInvalidAlert, req.MaxAge)
+		}
+		spec.MaxAge = maxAge
+	}
+	return spec, nil
+}
+
+// handleAlerts lists alerts (optionally for one chat_id) and creates them.
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var chatID int64
+		if value := r.URL.Query().Get("chat_id"); value != "" {
+			var err error
+			if chatID, err = strconv.ParseInt(value, 10, 64); err != nil {
+				writeError(w, http.StatusBadRequest, "chat_id must be a number")
+				return
+			}
+		}
+
+		alerts := []alertResponse{}
+		for _, alert := range s.alerts.Alerts(chatID) {
+			alerts = append(alerts, publicAlert(alert))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+	case http.MethodPost:
+		spec, ok := decodeAlertSpec(w, r)
+		if !ok {
+			return
+		}
+		alert, err := s.alerts.CreateAlert(r.Context(), spec)
+		if err != nil {
+			writeAlertError(w, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/alerts/"+alert.ID)
+		writeJSON(w, http.StatusCreated, publicAlert(alert))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleAlert reads, replaces or deletes one alert by ID.
+func (s *Server) handleAlert(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/alerts/")
+
+	switch r.Method {
+	case http.MethodGet:
+		alert, ok := s.alerts.Alert(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, telegram.ErrAlertNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, publicAlert(alert))
+	case http.MethodPut:
+		spec, ok := decodeAlertSpec(w, r)
+		if !ok {
+			return
+		}
+		alert, err := s.alerts.UpdateAlert(r.Context(), id, spec)
+		if err != nil {
+			writeAlertError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, publicAlert(alert))
+	case http.MethodDelete:
+		if err := s.alerts.DeleteAlert(id); err != nil {
+			writeAlertError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func decodeAlertSpec(w http.ResponseWriter, r *http.Request) (telegram.AlertSpec, bool) {
+	var body alertResponse
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return telegram.AlertSpec{}, false
+	}
+
+	spec, err := body.spec()
+	if err != nil {
+		writeAlertError(w, err)
+		return spec, false
+	}
+	return spec, true
+}
+
+func writeAlertError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, telegram.ErrInvalidAlert):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, telegram.ErrAlertNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, telegram.ErrAlertExists):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, telegram.ErrTooManyAlerts), errors.Is(err, telegram.ErrNotInPlan):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, telegram.ErrTopicsUnavailable):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		log.Printf("API alert request failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// publicAlert converts an alert to its API form, which leaves out the
+// topic embedding and gives max_age as a duration string.
+func publicAlert(alert models.UserAlert) alertResponse {
+	enabled := alert.Enabled
+	resp := alertResponse{
+		ID: alert.ID,
+		alertRequest: alertRequest{
+			ChatID:         alert.ChatID,
+			Name:           alert.Name,
+			Rule:           alert.Rule,
+			Categories:     alert.Categories,
+			Keywords:       alert.Keywords,
+			Tags:           alert.Tags,
+			Languages:      alert.Languages,
+			Topic:          alert.Topic,
+			TopicThreshold: alert.TopicThreshold,
+			MinConfidence:  alert.MinConfidence,
+			Sentiments:     alert.Sentiments,
+			Sources:        alert.Sources,
+			ExcludeSources: alert.ExcludeSources,
+			Delivery:       alert.Delivery,
+			DigestAt:       alert.DigestAt,
+			DigestOverview: alert.DigestOverview,
+			Enabled:        &enabled,
+		},
+		UserID:       alert.UserID,
+		LastDigestAt: alert.LastDigestAt,
+		CreatedAt:    alert.CreatedAt,
+	}
+	if alert.MaxAge > 0 {
+		resp.MaxAge = alert.MaxAge.String()
+	}
+	return resp
+}
+
+// handleFeedback reports the votes left on alerts, overall, per category
+// and per source.
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.alerts.FeedbackReport())
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/telegram"
+)
+
+// fakeAlertStore keeps alerts in memory and records the last spec it was
+// given, so tests can check how request bodies are translated.
+type fakeAlertStore struct {
+	alerts   []models.UserAlert
+	lastSpec telegram.AlertSpec
+	err      error
+}
+
+func (s *fakeAlertStore) Alerts(chatID int64) []models.UserAlert {
+	var alerts []models.UserAlert
+	for _, alert := range s.alerts {
+		if chatID == 0 || alert.ChatID == chatID {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func (s *fakeAlertStore) Alert(id string) (models.UserAlert, bool) {
+	for _, alert := range s.alerts {
+		if alert.ID == id {
+			return alert, true
+		}
+	}
+	return models.UserAlert{}, false
+}
+
+func (s *fakeAlertStore) CreateAlert(ctx context.Context, spec telegram.AlertSpec) (models.UserAlert, error) {
+	s.lastSpec = spec
+	if s.err != nil {
+		return models.UserAlert{}, s.err
+	}
+	alert := specAlert(spec)
+	alert.ID = fmt.Sprintf("a%d", len(s.alerts)+1)
+	alert.TopicEmbedding = []float64{0.1}
+	s.alerts = append(s.alerts, alert)
+	return alert, nil
+}
+
+func specAlert(spec telegram.AlertSpec) models.UserAlert {
+	return models.UserAlert{
+		ChatID:         spec.ChatID,
+		UserID:         spec.ChatID,
+		Name:           spec.Name,
+		Rule:           spec.Rule,
+		Enabled:        spec.Enabled,
+		Categories:     spec.Categories,
+		Keywords:       spec.Keywords,
+		Tags:           spec.Tags,
+		Languages:      spec.Languages,
+		Topic:          spec.Topic,
+		TopicThreshold: spec.TopicThreshold,
+		MinConfidence:  spec.MinConfidence,
+		Sentiments:     spec.Sentiments,
+		Sources:        spec.Sources,
+		ExcludeSources: spec.ExcludeSources,
+		MaxAge:         spec.MaxAge,
+		Delivery:       spec.Delivery,
+		DigestAt:       spec.DigestAt,
+		DigestOverview: spec.DigestOverview,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func (s *fakeAlertStore) UpdateAlert(ctx context.Context, id string, spec telegram.AlertSpec) (models.UserAlert, error) {
+	s.lastSpec = spec
+	for i, alert := range s.alerts {
+		if alert.ID == id {
+			if spec.Name == "" {
+				spec.Name = alert.Name
+			}
+			updated := specAlert(spec)
+			updated.ID, updated.ChatID, updated.CreatedAt = alert.ID, alert.ChatID, alert.CreatedAt
+			s.alerts[i] = updated
+			return updated, nil
+		}
+	}
+	return models.UserAlert{}, telegram.ErrAlertNotFound
+}
+
+func (s *fakeAlertStore) DeleteAlert(id string) error {
+	for i, alert := range s.alerts {
+		if alert.ID == id {
+			s.alerts = append(s.alerts[:i], s.alerts[i+1:]...)
+			return nil
+		}
+	}
+	return telegram.ErrAlertNotFound
+}
+
+func (s *fakeAlertStore) FeedbackReport() models.FeedbackReport {
+	return models.FeedbackReport{}
+}
+
+func do(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAlertRequestSpec(t *testing.T) {
+	store := &fakeAlertStore{}
+	server := newTestServer(t, nil, store)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   telegram.AlertSpec
+	}{
+		{
+			"rule with delivery",
+			`{"chat_id": 1, "name": "btc", "rule": "bitcoin", "delivery": "daily", "digest_at": "07:30", "digest_overview": true}`,
+			http.StatusCreated,
+			telegram.AlertSpec{ChatID: 1, Name: "btc", Rule: "bitcoin", Enabled: true, Delivery: "daily", DigestAt: "07:30", DigestOverview: true},
+		},
+		{
+			"keywords keep commas",
+			`{"chat_id": 1, "name": "kw", "keywords": ["1,000 BTC", "etf"], "max_age": "2h", "enabled": false}`,
+			http.StatusCreated,
+			telegram.AlertSpec{ChatID: 1, Name: "kw", Keywords: []string{"1,000 BTC", "etf"}, MaxAge: 2 * time.Hour},
+		},
+		{
+			"filters",
+			`{"chat_id": 1, "name": "f", "categories": ["crypto"], "min_confidence": 0.8, "topic": "rates", "topic_threshold": 0.5}`,
+			http.StatusCreated,
+			telegram.AlertSpec{ChatID: 1, Name: "f", Enabled: true, Categories: []string{"crypto"}, MinConfidence: 0.8, Topic: "rates", TopicThreshold: 0.5},
+		},
+		{"bad max_age", `{"chat_id": 1, "name": "x", "max_age": "soon"}`, http.StatusBadRequest, telegram.AlertSpec{}},
+		{"unknown field", `{"chat_id": 1, "name": "x", "colour": "red"}`, http.StatusBadRequest, telegram.AlertSpec{}},
+	}
+	for _, tt := range tests {
+		store.lastSpec = telegram.AlertSpec{}
+		resp := do(t, http.MethodPost, server.URL+"/api/v1/alerts", tt.body)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.status)
+			continue
+		}
+		if !reflect.DeepEqual(store.lastSpec, tt.want) {
+			t.Errorf("%s: spec = %+v, want %+v", tt.name, store.lastSpec, tt.want)
+		}
+	}
+}
+
+func TestAlertErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: bad", telegram.ErrInvalidAlert), http.StatusBadRequest},
+		{telegram.ErrAlertExists, http.StatusConflict},
+		{telegram.ErrTooManyAlerts, http.StatusForbidden},
+		{telegram.ErrNotInPlan, http.StatusForbidden},
+		{telegram.ErrTopicsUnavailable, http.StatusServiceUnavailable},
+		{fmt.Errorf("disk full"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		server := newTestServer(t, nil, &fakeAlertStore{err: tt.err})
+		resp := do(t, http.MethodPost, server.URL+"/api/v1/alerts", `{"chat_id": 1, "name": "x", "rule": "bitcoin"}`)
+		if resp.StatusCode != tt.want {
+			t.Errorf("%v: status = %d, want %d", tt.err, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestAlertCRUD(t *testing.T) {
+	store := &fakeAlertStore{}
+	server := newTestServer(t, nil, store)
+	url := server.URL + "/api/v1/alerts"
+
+	resp := do(t, http.MethodPost, url, `{"chat_id": 7, "name": "btc", "rule": "bitcoin"}`)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/api/v1/alerts/a1" {
+		t.Fatalf("create: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	created, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(created), "topic_embedding") {
+		t.Error("the topic embedding was exposed")
+	}
+
+	var list struct {
+		Alerts []alertResponse `json:"alerts"`
+	}
+	if err := json.NewDecoder(do(t, http.MethodGet, url+"?chat_id=7", "").Body).Decode(&list); err != nil || len(list.Alerts) != 1 {
+		t.Fatalf("list: %v, %+v", err, list)
+	}
+	if resp := do(t, http.MethodGet, url+"?chat_id=me", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("list with a bad chat_id: status %d", resp.StatusCode)
+	}
+
+	steps := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodGet, "/a1", "", http.StatusOK},
+		{http.MethodPut, "/a1", `{"name": "btc2", "rule": "bitcoin"}`, http.StatusOK},
+		{http.MethodPut, "/zz", `{"rule": "bitcoin"}`, http.StatusNotFound},
+		{http.MethodPatch, "/a1", "", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/a1", "", http.StatusNoContent},
+		{http.MethodGet, "/a1", "", http.StatusNotFound},
+		{http.MethodDelete, "/a1", "", http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if resp := do(t, step.method, url+step.path, step.body); resp.StatusCode != step.want {
+			t.Errorf("%s %s: status = %d, want %d", step.method, step.path, resp.StatusCode, step.want)
+		}
+	}
+}
+
+func TestAlertRoundTrip(t *testing.T) {
+	store := &fakeAlertStore{}
+	server := newTestServer(t, nil, store)
+	url := server.URL + "/api/v1/alerts"
+
+	body := `{"chat_id": 7, "name": "btc", "keywords": ["bitcoin"], "max_age": "90m", "delivery": "daily", "digest_at": "07:30"}`
+	if resp := do(t, http.MethodPost, url, body); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+	created := store.lastSpec
+
+	resp := do(t, http.MethodGet, url+"/a1", "")
+	fetched, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(fetched), `"max_age":"1h30m0s"`) {
+		t.Errorf("GET returned %s, want max_age as a duration string", fetched)
+	}
+
+	if resp := do(t, http.MethodPut, url+"/a1", string(fetched)); resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PUT of the fetched alert: status %d: %s", resp.StatusCode, message)
+	}
+	if !reflect.DeepEqual(store.lastSpec, created) {
+		t.Errorf("round trip changed the alert:\n got %+v\nwant %+v", store.lastSpec, created)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/apikey"
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/stream"
+	"github.com/ObiAU/hfnewsaggregator/internal/telegram"
+)
+
+const maxBodyBytes = 64 << 10
+
+// AlertStore manages subscriptions; the Telegram bot implements it.
+type AlertStore interface {
+	Alerts(chatID int64) []models.UserAlert
+	Alert(id string) (models.UserAlert, bool)
+	CreateAlert(ctx context.Context, spec telegram.AlertSpec) (models.UserAlert, error)
+	UpdateAlert(ctx context.Context, id string, spec telegram.AlertSpec) (models.UserAlert, error)
+	DeleteAlert(id string) error
+	FeedbackReport() models.FeedbackReport
+}
+
+// Server is the versioned JSON API under /api/v1/. Every request needs one
+// of the configured API keys.
+type Server struct {
+	keys   apikey.Keys
+	cache  *cache.Cache
+	alerts AlertStore
+	hub    *stream.Hub
+}
+
+func New(keys []string, cacheLayer *cache.Cache, alerts AlertStore, hub *stream.Hub) *Server {
+	return &Server{
+		keys:   apikey.New(keys),
+		cache:  cacheLayer,
+		alerts: alerts,
+		hub:    hub,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/articles", s.handleArticles)
+	mux.HandleFunc("/api/v1/articles/", s.handleArticle)
+	mux.HandleFunc("/api/v1/alerts", s.handleAlerts)
+	mux.HandleFunc("/api/v1/alerts/", s.handleAlert)
+	mux.HandleFunc("/api/v1/feedback", s.handleFeedback)
+	mux.HandleFunc("/api/v1/stream", s.handleSSE)
+	mux.HandleFunc("/api/v1/ws", s.handleWebSocket)
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	})
+	return s.authenticate(mux)
+}
+
+// authenticate accepts the key as "Authorization: Bearer <key>" or in the
+// X-API-Key header.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = strings.TrimSpace(bearer)
+		}
+
+		if !s.keys.Valid(key) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+const testKey = "secret"
+
+func newTestServer(t *testing.T, articles *cache.Cache, alerts AlertStore) *httptest.Server {
+	t.Helper()
+	if articles == nil {
+		articles = cache.New(time.Hour)
+		t.Cleanup(articles.Close)
+	}
+	server := httptest.NewServer(New([]string{testKey}, articles, alerts, nil).Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newTestServer(t, nil, &fakeAlertStore{})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"no key", nil, http.StatusUnauthorized},
+		{"wrong bearer", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"bearer", map[string]string{"Authorization": "Bearer " + testKey}, http.StatusOK},
+		{"header", map[string]string{"X-API-Key": testKey}, http.StatusOK},
+		{"bearer wins over header", map[string]string{"Authorization": "Bearer nope", "X-API-Key": testKey}, http.StatusUnauthorized},
+		{"not bearer", map[string]string{"Authorization": "Basic " + testKey}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		resp := get(t, server.URL+"/api/v1/articles", tt.headers)
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+		if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: missing WWW-Authenticate header", tt.name)
+		}
+	}
+}
+
+func TestArticlesPaging(t *testing.T) {
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		category := "cryptocurrency"
+		if i == 4 {
+			category = "sports"
+		}
+		articles.AddCategorizedArticle(models.CategorizedArticle{Article: models.Article{
+			Hash:        fmt.Sprintf("hash%d00000", i),
+			Title:       fmt.Sprintf("article %d", i),
+			Category:    category,
+			PublishedAt: base.Add(time.Duration(i) * time.Minute),
+		}})
+	}
+	server := newTestServer(t, articles, &fakeAlertStore{})
+	auth := map[string]string{"X-API-Key": testKey}
+
+	tests := []struct {
+		query      string
+		status     int
+		hashes     []string
+		total      int
+		nextOffset *int
+	}{
+		{"?limit=2", http.StatusOK, []string{"hash400000", "hash300000"}, 5, intPtr(2)},
+		{"?limit=2&offset=4", http.StatusOK, []string{"hash000000"}, 5, nil},
+		{"?category=crypto&limit=3&offset=1", http.StatusOK, []string{"hash200000", "hash100000", "hash000000"}, 4, nil},
+		{"?offset=10", http.StatusOK, nil, 5, nil},
+		{"?limit=0", http.StatusBadRequest, nil, 0, nil},
+		{"?limit=101", http.StatusBadRequest, nil, 0, nil},
+		{"?offset=-1", http.StatusBadRequest, nil, 0, nil},
+		{"?since=yesterday", http.StatusBadRequest, nil, 0, nil},
+	}
+	for _, tt := range tests {
+		resp := get(t, server.URL+"/api/v1/articles"+tt.query, auth)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.query, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+
+		var page articlePage
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		var hashes []string
+		for _, article := range page.Articles {
+			hashes = append(hashes, article.Hash)
+		}
+		if fmt.Sprint(hashes) != fmt.Sprint(tt.hashes) || page.Total != tt.total {
+			t.Errorf("%s: got %v (total %d), want %v (total %d)", tt.query, hashes, page.Total, tt.hashes, tt.total)
+		}
+		if (page.NextOffset == nil) != (tt.nextOffset == nil) || (page.NextOffset != nil && *page.NextOffset != *tt.nextOffset) {
+			t.Errorf("%s: next_offset = %v, want %v", tt.query, page.NextOffset, tt.nextOffset)
+		}
+	}
+}
+
+func TestArticleByPrefix(t *testing.T) {
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	articles.AddCategorizedArticle(models.CategorizedArticle{Article: models.Article{Hash: "abcdef123456", Title: "found"}})
+	server := newTestServer(t, articles, &fakeAlertStore{})
+	auth := map[string]string{"X-API-Key": testKey}
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"abcdef123456", http.StatusOK},
+		{"abcdef", http.StatusOK},
+		{"abcde", http.StatusNotFound},
+		{"ffffff", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if resp := get(t, server.URL+"/api/v1/articles/"+tt.id, auth); resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.id, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type articlePage struct {
+	Articles   []models.CategorizedArticle `json:"articles"`
+	Total      int                         `json:"total"`
+	Limit      int                         `json:"limit"`
+	Offset     int                         `json:"offset"`
+	NextOffset *int                        `json:"next_offset,omitempty"`
+}
+
+// handleArticles lists cached categorized articles, newest first.
+func (s *Server) handleArticles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	query, limit, offset, err := parseArticleQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	articles := s.cache.QueryCategorized(query)
+	page := articlePage{
+		Articles: []models.CategorizedArticle{},
+		Total:    len(articles),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if offset < len(articles) {
+		end := offset + limit
+		if end < len(articles) {
+			page.NextOffset = &end
+		} else {
+			end = len(articles)
+		}
+		page.Articles = articles[offset:end]
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// handleArticle returns one article by its hash or a unique prefix of it.
+func (s *Server) handleArticle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/articles/")
+	if len(id) < 6 || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "article not found")
+		return
+	}
+
+	article, ok := s.cache.FindCategorized(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "article not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, article)
+}
+
+func parseArticleQuery(r *http.Request) (cache.Query, int, int, error) {
+	values := r.URL.Query()
+	query := cache.Query{
+		Category:  rules.CanonicalCategory(values.Get("category")),
+		Source:    values.Get("source"),
+		Sentiment: values.Get("sentiment"),
+		Text:      values.Get("q"),
+	}
+	for _, tags := range values["tag"] {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+
+	var err error
+	if value := values.Get("min_confidence"); value != "" {
+		query.MinConfidence, err = strconv.ParseFloat(value, 64)
+		if err != nil || query.MinConfidence < 0 || query.MinConfidence > 1 {
+			return query, 0, 0, fmt.Errorf("min_confidence must be a number between 0 and 1")
+		}
+	}
+	if query.Since, err = parseTime(values.Get("since")); err != nil {
+		return query, 0, 0, fmt.Errorf("since: %w", err)
+	}
+	if query.Until, err = parseTime(values.Get("until")); err != nil {
+		return query, 0, 0, fmt.Errorf("until: %w", err)
+	}
+
+	limit, err := parseInt(values.Get("limit"), defaultPageSize)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return query, 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	offset, err := parseInt(values.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		return query, 0, 0, fmt.Errorf("offset must be a non-negative number")
+	}
+	return query, limit, offset, nil
+}
+
+// parseTime accepts an RFC 3339 timestamp or a duration such as 2h, meaning
+// that long ago.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a duration such as 2h, got %q", value)
+}
+
+func parseInt(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/stream"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+// feedMessage is what the WebSocket feed sends: articles and heartbeats.
+type feedMessage struct {
+	Type    string                     `json:"type"`
+	ID      string                     `json:"id,omitempty"`
+	Article *models.CategorizedArticle `json:"article,omitempty"`
+	Time    *time.Time                 `json:"time,omitempty"`
+}
+
+// openFeed parses the filter and resume point and subscribes to the hub,
+// writing an error response when that fails.
+func (s *Server) openFeed(w http.ResponseWriter, r *http.Request, lastEventID string) (*stream.Feed, bool) {
+	query, _, _, err := parseArticleQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	feed, err := s.hub.Open(query, lastEventID)
+	switch {
+	case errors.Is(err, stream.ErrInvalidEventID):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	case err != nil:
+		if errors.Is(err, stream.ErrTooManySubscribers) {
+			w.Header().Set("Retry-After", "30")
+		}
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return nil, false
+	}
+	return feed, true
+}
+
+// handleSSE streams articles as Server-Sent Events. Reconnecting clients
+// send Last-Event-ID (or ?last_event_id=) to replay what they missed from
+// the cache.
+func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	feed, ok := s.openFeed(w, r, lastEventID)
+	if !ok {
+		return
+	}
+	defer feed.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	send := func(article models.CategorizedArticle) error {
+		id, ok := feed.Next(article)
+		if !ok {
+			return nil
+		}
+		data, err := json.Marshal(article)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %s\nevent: article\ndata: %s\n\n", id, data)
+		return err
+	}
+
+	if err := feed.Replay(send); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case article, ok := <-feed.Events():
+			if !ok {
+				return
+			}
+			if err := send(article); err != nil {
+				return
+			}
+		case now := <-heartbeat.C:
+			if _, err := fmt.Fprintf(w, "event: heartbeat\ndata: {\"time\":%q}\n\n", now.UTC().Format(time.RFC3339)); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// handleWebSocket streams the same feed as JSON messages over a WebSocket.
+// Clients resume with ?last_event_id=.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	feed, ok := s.openFeed(w, r, r.URL.Query().Get("last_event_id"))
+	if !ok {
+		return
+	}
+	defer feed.Close()
+
+	// Clients authenticate with an API key rather than cookies, so any
+	// origin may connect.
+	server := websocket.Server{
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			s.serveWebSocket(conn, feed)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (s *Server) serveWebSocket(conn *websocket.Conn, feed *stream.Feed) {
+	defer conn.Close()
+
+	// The feed is one-way; reading only notices when the client goes away.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+
+	send := func(msg feedMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return websocket.JSON.Send(conn, msg)
+	}
+	sendArticle := func(article models.CategorizedArticle) error {
+		id, ok := feed.Next(article)
+		if !ok {
+			return nil
+		}
+		return send(feedMessage{Type: "article", ID: id, Article: &article})
+	}
+
+	if err := feed.Replay(sendArticle); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case article, ok := <-feed.Events():
+			if !ok {
+				return
+			}
+			if err := sendArticle(article); err != nil {
+				return
+			}
+		case now := <-heartbeat.C:
+			now = now.UTC()
+			if err := send(feedMessage{Type: "heartbeat", Time: &now}); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/stream"
+)
+
+// readEventIDs reads SSE "id:" lines until n have arrived.
+func readEventIDs(t *testing.T, resp *http.Response, n int) []string {
+	t.Helper()
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < n && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < n {
+		t.Fatalf("stream ended after %v, want %d events: %v", ids, n, scanner.Err())
+	}
+	return ids
+}
+
+func TestSSEResume(t *testing.T) {
+	base := time.Now().Add(-time.Minute)
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	var processed []models.CategorizedArticle
+	for i, hash := range []string{"aaa", "bbb", "ccc"} {
+		article := models.CategorizedArticle{
+			Article:     models.Article{Hash: hash, Category: "technology"},
+			ProcessedAt: base.Add(time.Duration(i) * time.Second),
+		}
+		articles.AddCategorizedArticle(article)
+		processed = append(processed, article)
+	}
+	hub := stream.NewHub(articles)
+	t.Cleanup(hub.Close)
+	server := httptest.NewServer(New([]string{testKey}, articles, &fakeAlertStore{}, hub).Handler())
+	t.Cleanup(server.Close)
+
+	idOf := func(i int) string { return stream.CursorOf(processed[i]).EventID() }
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		want   []string
+	}{
+		{"Last-Event-ID header", idOf(0), "", []string{idOf(1), idOf(2)}},
+		{"query parameter", "", "?last_event_id=" + idOf(1), []string{idOf(2)}},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/stream"+tt.query, nil)
+		req.Header.Set("X-API-Key", testKey)
+		if tt.header != "" {
+			req.Header.Set("Last-Event-ID", tt.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := readEventIDs(t, resp, len(tt.want)); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: replayed %v, want %v", tt.name, got, tt.want)
+		}
+		cancel()
+		resp.Body.Close()
+	}
+
+	resp := get(t, server.URL+"/api/v1/stream", map[string]string{"X-API-Key": testKey, "Last-Event-ID": "nonsense"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad Last-Event-ID: status %d, want 400", resp.StatusCode)
+	}
+}
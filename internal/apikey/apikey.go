@@ -0,0 +1,34 @@
+package apikey
+
+import "crypto/subtle"
+
+// Keys is the set of API keys accepted by the REST and gRPC APIs.
+type Keys struct {
+	keys [][]byte
+}
+
+func New(keys []string) Keys {
+	var k Keys
+	for _, key := range keys {
+		if key != "" {
+			k.keys = append(k.keys, []byte(key))
+		}
+	}
+	return k
+}
+
+// Valid reports whether key is one of the configured keys. Every candidate
+// is compared in constant time so the check does not leak which one matched.
+func (k Keys) Valid(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	valid := false
+	for _, candidate := range k.keys {
+		if subtle.ConstantTimeCompare([]byte(key), candidate) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
@@ -0,0 +1,28 @@
+package apikey
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	keys := New([]string{"alpha", "", "beta"})
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"alpha", true},
+		{"beta", true},
+		{"", false},
+		{"alph", false},
+		{"alphaa", false},
+		{"gamma", false},
+	}
+	for _, tt := range tests {
+		if got := keys.Valid(tt.key); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	if New(nil).Valid("alpha") {
+		t.Error("an empty key set accepted a key")
+	}
+}
@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/ObiAU/hfnewsaggregator/internal/models"
 )
@@ -10,6 +13,7 @@ import (
 type Cache struct {
 	mu            sync.RWMutex
 	articles      map[string]models.Article
+	categorized   map[string]models.CategorizedArticle
 	processed     map[string]time.Time
 	retention     time.Duration
 	cleanupTicker *time.Ticker
@@ -18,10 +22,11 @@ type Cache struct {
 
 func New(retention time.Duration) *Cache {
 	c := &Cache{
-		articles:  make(map[string]models.Article),
-		processed: make(map[string]time.Time),
-		retention: retention,
-		stopChan:  make(chan struct{}),
+		articles:    make(map[string]models.Article),
+		categorized: make(map[string]models.CategorizedArticle),
+		processed:   make(map[string]time.Time),
+		retention:   retention,
+		stopChan:    make(chan struct{}),
 	}
 
 	c.cleanupTicker = time.NewTicker(1 * time.Hour)
@@ -38,6 +43,228 @@ func (c *Cache) AddArticle(article models.Article) {
 	c.processed[article.Hash] = time.Now()
 }
 
+func (c *Cache) AddCategorizedArticle(article models.CategorizedArticle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.articles[article.Hash] = article.Article
+	c.categorized[article.Hash] = article
+	c.processed[article.Hash] = time.Now()
+}
+
+func (c *Cache) GetCategorizedArticle(hash string) (models.CategorizedArticle, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	article, exists := c.categorized[hash]
+	return article, exists
+}
+
+// Query selects categorized articles. Zero fields are not applied. Source
+// matches the feed or the outlet it reports, and Tags match any one tag.
+type Query struct {
+	Category      string
+	Source        string
+	Tags          []string
+	Sentiment     string
+	MinConfidence float64
+	Since         time.Time
+	Until         time.Time
+	Text          string
+	Limit         int
+}
+
+// Matches reports whether article passes every filter of the query. Text
+// matches when every word appears in the title, content, summary or tags,
+// ignoring case.
+func (q Query) Matches(article models.CategorizedArticle) bool {
+	switch {
+	case q.Category != "" && !strings.EqualFold(article.Category, q.Category):
+		return false
+	case q.Source != "" && !strings.EqualFold(article.Source, q.Source) && !strings.EqualFold(article.Metadata["source_name"], q.Source):
+		return false
+	case len(q.Tags) > 0 && !hasAnyTag(article, q.Tags):
+		return false
+	case q.Sentiment != "" && !strings.EqualFold(article.Sentiment, q.Sentiment):
+		return false
+	case article.Confidence < q.MinConfidence:
+		return false
+	case !q.Since.IsZero() && article.PublishedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && article.PublishedAt.After(q.Until):
+		return false
+	}
+
+	words := strings.Fields(strings.ToLower(q.Text))
+	return len(words) == 0 || matchesWords(article, words)
+}
+
+// QueryCategorized returns matching categorized articles, newest first.
+func (c *Cache) QueryCategorized(q Query) []models.CategorizedArticle {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var results []models.CategorizedArticle
+	for _, article := range c.categorized {
+		if q.Matches(article) {
+			results = append(results, article)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].PublishedAt.After(results[j].PublishedAt)
+	})
+
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// CategorizedAfter returns up to limit articles processed after the given
+// point, oldest first. Articles processed at the same instant are ordered by
+// hash, which is compared on the length of the hash given. When more than
+// limit match, the oldest ones are returned, so callers can page by passing
+// the last article back in.
+func (c *Cache) CategorizedAfter(processedAt time.Time, hash string, limit int) []models.CategorizedArticle {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var results []models.CategorizedArticle
+	for _, article := range c.categorized {
+		if article.ProcessedAt.After(processedAt) ||
+			(article.ProcessedAt.Equal(processedAt) && hashPrefix(article.Hash, len(hash)) > hash) {
+			results = append(results, article)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].ProcessedAt.Equal(results[j].ProcessedAt) {
+			return results[i].ProcessedAt.Before(results[j].ProcessedAt)
+		}
+		return results[i].Hash < results[j].Hash
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func hashPrefix(hash string, n int) string {
+	if len(hash) > n {
+		return hash[:n]
+	}
+	return hash
+}
+
+// RankCategorized scores articles by how many distinct words of text they
+// contain and returns the best matches, newest first among equal scores.
+// Words shorter than three letters and common question words are ignored.
+func (c *Cache) RankCategorized(text string, limit int) []models.CategorizedArticle {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 3 && !ignoredWords[word] {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	type scored struct {
+		article models.CategorizedArticle
+		score   int
+	}
+
+	var results []scored
+	for _, article := range c.categorized {
+		score := 0
+		for _, word := range words {
+			if matchesWords(article, []string{word}) {
+				score++
+			}
+		}
+		if score > 0 {
+			results = append(results, scored{article: article, score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].article.PublishedAt.After(results[j].article.PublishedAt)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	articles := make([]models.CategorizedArticle, len(results))
+	for i, result := range results {
+		articles[i] = result.article
+	}
+	return articles
+}
+
+// FindCategorized looks up an article by a prefix of its hash. It fails if
+// the prefix is ambiguous.
+func (c *Cache) FindCategorized(prefix string) (models.CategorizedArticle, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if article, ok := c.categorized[prefix]; ok {
+		return article, true
+	}
+
+	var found models.CategorizedArticle
+	matches := 0
+	for hash, article := range c.categorized {
+		if strings.HasPrefix(hash, prefix) {
+			found = article
+			matches++
+		}
+	}
+	return found, matches == 1
+}
+
+var ignoredWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "were": true,
+	"why": true, "what": true, "how": true, "who": true, "when": true, "where": true,
+	"which": true, "did": true, "does": true, "with": true, "this": true, "that": true,
+	"about": true, "today": true, "news": true, "happened": true, "happening": true,
+}
+
+func hasAnyTag(article models.CategorizedArticle, tags []string) bool {
+	for _, tag := range article.Tags {
+		for _, wanted := range tags {
+			if strings.EqualFold(tag, wanted) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesWords(article models.CategorizedArticle, words []string) bool {
+	text := strings.ToLower(strings.Join([]string{
+		article.Title, article.Content, article.Summary, strings.Join(article.Tags, " "),
+	}, " "))
+
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Cache) HasArticle(hash string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -95,6 +322,7 @@ func (c *Cache) performCleanup() {
 	for hash, processedTime := range c.processed {
 		if processedTime.Before(cutoff) {
 			delete(c.articles, hash)
+			delete(c.categorized, hash)
 			delete(c.processed, hash)
 		}
 	}
@@ -111,6 +339,7 @@ func (c *Cache) Stats() map[string]interface{} {
 
 	return map[string]interface{}{
 		"total_articles": len(c.articles),
+		"categorized":    len(c.categorized),
 		"processed":      len(c.processed),
 		"retention":      c.retention.String(),
 	}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,11 +12,33 @@ type Config struct {
 	OpenAIAPIKey       string
 	TelegramToken      string
 	TelegramWebhookURL string
+	TelegramParseMode  string
+	AdminUserIDs       []int64
+	QuotaFile          string
+	FeedbackFile       string
+	NotifiersFile      string
+	SMTPHost           string
+	SMTPPort           string
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	EmailSubscribers   string
+	PublicBaseURL      string
+	APIKeys            []string
 	BatchSize          int
 	ProcessingInterval time.Duration
 	CacheRetention     time.Duration
 	ServerPort         string
+	GRPCPort           string
 	LogLevel           string
+	SemanticThreshold  float64
+	TranslateTo        string
+	EnrichFullText     bool
+	EnrichMaxBytes     int
+	EnrichTimeout      time.Duration
+	EnrichUserAgent    string
+	LLMRecordMode      string
+	LLMFixturesDir     string
 }
 
 func Load() *Config {
@@ -24,11 +47,33 @@ func Load() *Config {
 		OpenAIAPIKey:       getEnv("OPENAI_API_KEY", ""),
 		TelegramToken:      getEnv("TELEGRAM_BOT_TOKEN", ""),
 		TelegramWebhookURL: getEnv("TELEGRAM_WEBHOOK_URL", ""),
+		TelegramParseMode:  getEnv("TELEGRAM_PARSE_MODE", "HTML"),
+		AdminUserIDs:       getEnvAsInt64List("ADMIN_USER_IDS"),
+		QuotaFile:          getEnv("QUOTA_FILE", "data/quotas.json"),
+		FeedbackFile:       getEnv("FEEDBACK_FILE", "data/feedback.json"),
+		NotifiersFile:      getEnv("NOTIFIERS_FILE", ""),
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           getEnv("SMTP_PORT", "587"),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:           getEnv("SMTP_FROM", ""),
+		EmailSubscribers:   getEnv("EMAIL_SUBSCRIBERS_FILE", "data/email_subscribers.json"),
+		PublicBaseURL:      getEnv("PUBLIC_BASE_URL", ""),
+		APIKeys:            getEnvAsList("API_KEYS"),
 		BatchSize:          getEnvAsInt("BATCH_SIZE", 10),
 		ProcessingInterval: getEnvAsDuration("PROCESSING_INTERVAL", 30*time.Second),
 		CacheRetention:     getEnvAsDuration("CACHE_RETENTION", 24*time.Hour),
 		ServerPort:         getEnv("SERVER_PORT", "8080"),
+		GRPCPort:           getEnv("GRPC_PORT", "9090"),
 		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		SemanticThreshold:  getEnvAsFloat("SEMANTIC_THRESHOLD", 0.5),
+		TranslateTo:        getEnv("TRANSLATE_TO", ""),
+		EnrichFullText:     getEnvAsBool("ENRICH_FULL_TEXT", false),
+		EnrichMaxBytes:     getEnvAsInt("ENRICH_MAX_BYTES", 2*1024*1024),
+		EnrichTimeout:      getEnvAsDuration("ENRICH_TIMEOUT", 10*time.Second),
+		EnrichUserAgent:    getEnv("ENRICH_USER_AGENT", "HFNewsAggregator/1.0"),
+		LLMRecordMode:      getEnv("LLM_RECORD_MODE", ""),
+		LLMFixturesDir:     getEnv("LLM_FIXTURES_DIR", "testdata/llm"),
 	}
 }
 
@@ -48,6 +93,46 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsList splits a comma-separated list, dropping empty entries.
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getEnvAsInt64List parses a comma-separated list, skipping invalid entries.
+func getEnvAsInt64List(key string) []int64 {
+	var values []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if value, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -0,0 +1,325 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	ErrDisallowed     = errors.New("disallowed by robots.txt")
+	ErrNotHTML        = errors.New("response is not html")
+	ErrNoMainText     = errors.New("no readable main text found")
+	ErrUnsupported    = errors.New("unsupported url")
+	ErrPrivateAddress = errors.New("refusing to fetch a non-public address")
+)
+
+const (
+	minParagraphLength = 40
+	maxRedirects       = 5
+)
+
+type Extractor struct {
+	client       *http.Client
+	robotsClient *http.Client
+	userAgent    string
+	maxBytes     int64
+	robots       map[string]*robotsRules
+	mu           sync.RWMutex
+}
+
+// New returns an extractor that only connects to public IP addresses, since
+// article URLs come from third-party feeds and the extracted text is
+// published through the APIs.
+func New(userAgent string, maxBytes int64, timeout time.Duration) *Extractor {
+	return newExtractor(userAgent, maxBytes, timeout, checkPublicIP)
+}
+
+// newExtractor builds the extractor; checkIP vets every address dialled,
+// including after redirects, and may be nil to allow any address.
+func newExtractor(userAgent string, maxBytes int64, timeout time.Duration, checkIP func(net.IP) error) *Extractor {
+	dialer := &net.Dialer{Timeout: timeout}
+	if checkIP != nil {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkIP(net.ParseIP(host))
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	e := &Extractor{
+		userAgent: userAgent,
+		maxBytes:  maxBytes,
+		robots:    make(map[string]*robotsRules),
+	}
+	e.client = &http.Client{
+		Transport:     transport,
+		Timeout:       timeout,
+		CheckRedirect: e.checkRedirect,
+	}
+	e.robotsClient = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+	return e
+}
+
+// checkRedirect applies the same URL and robots.txt checks to every hop, as
+// feed links usually redirect to a different host.
+func (e *Extractor) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return ErrUnsupported
+	}
+
+	allowed, err := e.robotsAllowed(req.Context(), req.URL)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrDisallowed
+	}
+	return nil
+}
+
+func checkPublicIP(ip net.IP) error {
+	if ip == nil || !isPublicIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP does not
+// count as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// Extract fetches rawURL and returns its readable main text with navigation,
+// scripts and other page chrome removed.
+func (e *Extractor) Extract(ctx context.Context, rawURL string) (string, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", ErrUnsupported
+	}
+
+	allowed, err := e.robotsAllowed(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", ErrDisallowed
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", e.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("article page returned status %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", ErrNotHTML
+	}
+
+	if resp.ContentLength > e.maxBytes {
+		return "", fmt.Errorf("article page is %d bytes, limit is %d", resp.ContentLength, e.maxBytes)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, e.maxBytes))
+	if err != nil {
+		return "", err
+	}
+
+	text := mainText(doc)
+	if text == "" {
+		return "", ErrNoMainText
+	}
+
+	return text, nil
+}
+
+var removedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Button:   true,
+	atom.Figure:   true,
+}
+
+var (
+	positiveHints = []string{"article", "content", "story", "post", "entry", "main", "body", "text"}
+	negativeHints = []string{"comment", "footer", "sidebar", "nav", "menu", "share", "social", "related", "promo", "advert", "cookie", "newsletter", "subscribe", "popup", "breadcrumb"}
+)
+
+// mainText scores every element by the paragraph text beneath it, in the
+// spirit of readability: each paragraph credits its parent fully and its
+// grandparent by half, and class/id names nudge the score up or down.
+func mainText(doc *html.Node) string {
+	prune(doc)
+
+	scores := make(map[*html.Node]float64)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Blockquote) {
+			text := nodeText(n)
+			if len(text) >= minParagraphLength {
+				score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+				if parent := n.Parent; parent != nil {
+					scores[parent] += score + classWeight(parent)
+					if grandparent := parent.Parent; grandparent != nil {
+						scores[grandparent] += score/2 + classWeight(grandparent)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, score := range scores {
+		if score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+
+	var paragraphs []string
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.P, atom.Pre, atom.Blockquote, atom.Li, atom.H2, atom.H3:
+				if text := nodeText(n); text != "" {
+					paragraphs = append(paragraphs, text)
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(best)
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && (removedElements[c.DataAtom] || isBoilerplate(c))) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	return classWeight(n) < 0 && !hasHint(n, positiveHints)
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	if hasHint(n, negativeHints) {
+		weight -= 25
+	}
+	if hasHint(n, positiveHints) {
+		weight += 25
+	}
+	if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		weight += 10
+	}
+	return weight
+}
+
+func hasHint(n *html.Node, hints []string) bool {
+	var names string
+	for _, attr := range n.Attr {
+		if attr.Key == "class" || attr.Key == "id" || attr.Key == "role" {
+			names += " " + strings.ToLower(attr.Val)
+		}
+	}
+	if names == "" {
+		return false
+	}
+
+	for _, hint := range hints {
+		if strings.Contains(names, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
@@ -0,0 +1,120 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const articlePage = `<html><body>
+<nav><p>Home, Markets, Crypto, Economy, Opinion, Newsletters, Podcasts</p></nav>
+<div class="article-body">
+<p>Spot bitcoin exchange-traded funds took in more than $1 billion on Tuesday, the largest daily inflow since launch.</p>
+<p>Analysts said the demand came mostly from wealth managers, who added to positions after a quiet month.</p>
+</div>
+<div class="comments"><p>Great article, thanks for sharing this with everyone here today!</p></div>
+<footer><p>Copyright, all rights reserved, terms of use, privacy policy, cookies</p></footer>
+</body></html>`
+
+func TestMainText(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(articlePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := mainText(doc)
+	if !strings.Contains(text, "largest daily inflow") || !strings.Contains(text, "wealth managers") {
+		t.Errorf("main text missing article paragraphs: %q", text)
+	}
+	for _, chrome := range []string{"Newsletters", "Great article", "Copyright"} {
+		if strings.Contains(text, chrome) {
+			t.Errorf("main text includes page chrome %q: %q", chrome, text)
+		}
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:4700:4700::1111": true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"::ffff:127.0.0.1":     false,
+	}
+	for addr, want := range tests {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestExtractRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(articlePage))
+	}))
+	defer server.Close()
+
+	e := New("HFNewsAggregator/1.0", 1<<20, 5*time.Second)
+	if _, err := e.Extract(context.Background(), server.URL+"/story"); !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("Extract from loopback: got %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestExtractChecksRobotsAfterRedirect(t *testing.T) {
+	publisher := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: hfnewsaggregator\nDisallow: /paywalled/\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(articlePage))
+	}))
+	defer publisher.Close()
+
+	shortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, publisher.URL+strings.TrimPrefix(r.URL.Path, "/go"), http.StatusFound)
+	}))
+	defer shortener.Close()
+
+	e := newExtractor("HFNewsAggregator/1.0", 1<<20, 5*time.Second, nil)
+
+	if _, err := e.Extract(context.Background(), shortener.URL+"/go/paywalled/story"); !errors.Is(err, ErrDisallowed) {
+		t.Fatalf("redirect to a disallowed path: got %v, want ErrDisallowed", err)
+	}
+
+	text, err := e.Extract(context.Background(), shortener.URL+"/go/markets/story")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "largest daily inflow") {
+		t.Errorf("unexpected text %q", text)
+	}
+}
+
+func TestExtractRejectsUnsupportedURLs(t *testing.T) {
+	e := newExtractor("HFNewsAggregator/1.0", 1<<20, time.Second, nil)
+	for _, rawURL := range []string{"ftp://example.com/a", "file:///etc/passwd", "not a url", "https://"} {
+		if _, err := e.Extract(context.Background(), rawURL); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Extract(%q): got %v, want ErrUnsupported", rawURL, err)
+		}
+	}
+}
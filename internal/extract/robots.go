@@ -0,0 +1,186 @@
+package extract
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const robotsTTL = 6 * time.Hour
+
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+type robotsRules struct {
+	rules     []robotsRule
+	fetchedAt time.Time
+}
+
+// allowed applies the longest matching rule, with Allow winning ties, as
+// described in RFC 9309.
+func (r *robotsRules) allowed(path string) bool {
+	best := robotsRule{allow: true}
+	bestLen := -1
+
+	for _, rule := range r.rules {
+		if rule.path == "" || !robotsPathMatches(rule.path, path) {
+			continue
+		}
+		if len(rule.path) > bestLen || (len(rule.path) == bestLen && rule.allow) {
+			best = rule
+			bestLen = len(rule.path)
+		}
+	}
+
+	return best.allow
+}
+
+func robotsPathMatches(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+
+	return !anchored || rest == "" || strings.HasSuffix(pattern, "*")
+}
+
+func (e *Extractor) robotsAllowed(ctx context.Context, target *url.URL) (bool, error) {
+	host := target.Scheme + "://" + target.Host
+
+	e.mu.RLock()
+	rules, exists := e.robots[host]
+	e.mu.RUnlock()
+
+	if !exists || time.Since(rules.fetchedAt) > robotsTTL {
+		fetched, err := e.fetchRobots(ctx, host)
+		if err != nil {
+			return false, err
+		}
+
+		e.mu.Lock()
+		e.robots[host] = fetched
+		e.mu.Unlock()
+		rules = fetched
+	}
+
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+
+	return rules.allowed(path), nil
+}
+
+func (e *Extractor) fetchRobots(ctx context.Context, host string) (*robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", host+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", e.userAgent)
+
+	resp, err := e.robotsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		// Server errors mean the site is unreachable for crawling purposes.
+		return &robotsRules{rules: []robotsRule{{allow: false, path: "/"}}, fetchedAt: time.Now()}, nil
+	case resp.StatusCode != http.StatusOK:
+		return &robotsRules{fetchedAt: time.Now()}, nil
+	}
+
+	rules := parseRobots(io.LimitReader(resp.Body, 512*1024), e.agentToken())
+	rules.fetchedAt = time.Now()
+	return rules, nil
+}
+
+func (e *Extractor) agentToken() string {
+	return productToken(e.userAgent)
+}
+
+// productToken returns the lowercased name of a user agent, without its
+// version or comments: "HFNewsAggregator/1.0" becomes "hfnewsaggregator".
+func productToken(agent string) string {
+	agent = strings.TrimSpace(agent)
+	if idx := strings.IndexAny(agent, "/ "); idx > 0 {
+		agent = agent[:idx]
+	}
+	return strings.ToLower(agent)
+}
+
+// parseRobots keeps the rules of the group addressed to agent, falling back
+// to the wildcard group when no specific group exists. Groups match on the
+// whole product token, so a "news" group does not apply to
+// "hfnewsaggregator".
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	var specific, wildcard []robotsRule
+	var groupAgents []string
+	inRules := false
+	hasSpecific := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, productToken(value))
+		case "allow", "disallow":
+			inRules = true
+			rule := robotsRule{allow: key == "allow", path: value}
+			for _, groupAgent := range groupAgents {
+				switch {
+				case groupAgent == "*":
+					wildcard = append(wildcard, rule)
+				case agent != "" && agent == groupAgent:
+					specific = append(specific, rule)
+					hasSpecific = true
+				}
+			}
+		}
+	}
+
+	if hasSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRobotsAgentMatching(t *testing.T) {
+	const robots = `
+User-agent: *
+Disallow: /private/
+
+User-agent: news
+Disallow: /
+
+User-agent: HFNewsAggregator/2.0
+Disallow: /drafts/
+Allow: /drafts/public
+`
+
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"hfnewsaggregator", "/drafts/story", false},
+		{"hfnewsaggregator", "/drafts/public/story", true},
+		{"hfnewsaggregator", "/private/x", true},
+		{"hfnewsaggregator", "/markets", true},
+		{"news", "/markets", false},
+		{"otherbot", "/private/x", false},
+		{"otherbot", "/markets", true},
+	}
+
+	for _, tt := range tests {
+		rules := parseRobots(strings.NewReader(robots), tt.agent)
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("agent %s, path %s: allowed = %v, want %v", tt.agent, tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestParseRobotsGroups(t *testing.T) {
+	const robots = `# comment
+User-agent: a
+User-agent: b
+Disallow: /shared # trailing comment
+
+User-agent: c
+Disallow:
+`
+
+	if parseRobots(strings.NewReader(robots), "b").allowed("/shared/page") {
+		t.Error("second agent in a group should get the group's rules")
+	}
+	if !parseRobots(strings.NewReader(robots), "c").allowed("/shared/page") {
+		t.Error("an empty Disallow allows everything")
+	}
+}
+
+func TestRobotsPathMatches(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/news", "/news/today", true},
+		{"/news", "/new", false},
+		{"/*.pdf", "/files/report.pdf", true},
+		{"/*.pdf$", "/files/report.pdf?x=1", false},
+		{"/*.pdf$", "/files/report.pdf", true},
+		{"/a*b*c", "/a-x-b-y-c-z", true},
+		{"/a*b*c", "/a-x-c-y-b", false},
+	}
+
+	for _, tt := range tests {
+		if got := robotsPathMatches(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("robotsPathMatches(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLongestRuleWins(t *testing.T) {
+	rules := &robotsRules{rules: []robotsRule{
+		{allow: false, path: "/page"},
+		{allow: true, path: "/page"},
+		{allow: false, path: "/page/secret"},
+	}}
+
+	if !rules.allowed("/page/open") {
+		t.Error("Allow should win a tie with Disallow")
+	}
+	if rules.allowed("/page/secret/x") {
+		t.Error("the longest matching rule should win")
+	}
+}
+
+func TestProductToken(t *testing.T) {
+	tests := map[string]string{
+		"HFNewsAggregator/1.0":              "hfnewsaggregator",
+		"  Googlebot ":                      "googlebot",
+		"Mozilla/5.0 (compatible; Bot/2.1)": "mozilla",
+		"*":                                 "*",
+	}
+	for in, want := range tests {
+		if got := productToken(in); got != want {
+			t.Errorf("productToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
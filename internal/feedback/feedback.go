@@ -0,0 +1,118 @@
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/fileutil"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// Store keeps one vote per user, alert and article, rewriting its JSON file
+// after every vote when it has one.
+type Store struct {
+	path  string
+	votes map[string]models.Feedback
+	mu    sync.Mutex
+}
+
+// NewStore returns a store that only lives in memory.
+func NewStore() *Store {
+	return &Store{votes: make(map[string]models.Feedback)}
+}
+
+// Open loads the store saved at path, starting empty if the file does not
+// exist yet.
+func Open(path string) (*Store, error) {
+	s := NewStore()
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []models.Feedback
+	if err := json.Unmarshal(data, &votes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, vote := range votes {
+		s.votes[key(vote)] = vote
+	}
+	return s, nil
+}
+
+// Record saves a vote, replacing the user's earlier vote on the same alert
+// and article.
+func (s *Store) Record(vote models.Feedback) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.votes[key(vote)] = vote
+	return s.saveLocked()
+}
+
+// Report aggregates votes by category and by source. Precision is the share
+// of votes that did not flag the category as wrong; usefulness is the share
+// marked relevant.
+func (s *Store) Report() models.FeedbackReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := models.FeedbackReport{
+		Categories: make(map[string]models.FeedbackStats),
+		Sources:    make(map[string]models.FeedbackStats),
+	}
+
+	for _, vote := range s.votes {
+		report.Overall = add(report.Overall, vote.Verdict)
+		report.Categories[vote.Category] = add(report.Categories[vote.Category], vote.Verdict)
+		report.Sources[vote.Source] = add(report.Sources[vote.Source], vote.Verdict)
+	}
+
+	return report
+}
+
+func add(stats models.FeedbackStats, verdict string) models.FeedbackStats {
+	stats.Total++
+	switch verdict {
+	case models.FeedbackRelevant:
+		stats.Relevant++
+	case models.FeedbackIrrelevant:
+		stats.Irrelevant++
+	case models.FeedbackWrongCategory:
+		stats.WrongCategory++
+	}
+
+	stats.Precision = float64(stats.Total-stats.WrongCategory) / float64(stats.Total)
+	stats.Usefulness = float64(stats.Relevant) / float64(stats.Total)
+	return stats
+}
+
+// key includes the voter so that in a group every member's vote counts.
+func key(vote models.Feedback) string {
+	return strconv.FormatInt(vote.UserID, 10) + ":" + vote.AlertID + ":" + vote.ArticleHash
+}
+
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	votes := make([]models.Feedback, 0, len(s.votes))
+	for _, vote := range s.votes {
+		votes = append(votes, vote)
+	}
+	data, err := json.MarshalIndent(votes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteAtomic(s.path, data)
+}
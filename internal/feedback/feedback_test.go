@@ -0,0 +1,70 @@
+package feedback
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func vote(userID int64, alertID, hash, category, source, verdict string) models.Feedback {
+	return models.Feedback{UserID: userID, AlertID: alertID, ArticleHash: hash, Category: category, Source: source, Verdict: verdict}
+}
+
+func TestRecordKeepsOneVotePerUser(t *testing.T) {
+	s := NewStore()
+	s.Record(vote(1, "A1", "h1", "finance", "reuters", models.FeedbackIrrelevant))
+	s.Record(vote(1, "A1", "h1", "finance", "reuters", models.FeedbackRelevant))
+	s.Record(vote(2, "A1", "h1", "finance", "reuters", models.FeedbackWrongCategory))
+
+	report := s.Report()
+	want := models.FeedbackStats{Total: 2, Relevant: 1, WrongCategory: 1, Precision: 0.5, Usefulness: 0.5}
+	if report.Overall != want {
+		t.Errorf("overall = %+v, want %+v", report.Overall, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	s := NewStore()
+	s.Record(vote(1, "A1", "h1", "finance", "reuters", models.FeedbackRelevant))
+	s.Record(vote(1, "A1", "h2", "finance", "bloomberg", models.FeedbackWrongCategory))
+	s.Record(vote(1, "A2", "h3", "sports", "reuters", models.FeedbackIrrelevant))
+	s.Record(vote(1, "A2", "h4", "sports", "reuters", models.FeedbackRelevant))
+
+	tests := []struct {
+		name string
+		got  models.FeedbackStats
+		want models.FeedbackStats
+	}{
+		{"finance", s.Report().Categories["finance"], models.FeedbackStats{Total: 2, Relevant: 1, WrongCategory: 1, Precision: 0.5, Usefulness: 0.5}},
+		{"sports", s.Report().Categories["sports"], models.FeedbackStats{Total: 2, Relevant: 1, Irrelevant: 1, Precision: 1, Usefulness: 0.5}},
+		{"reuters", s.Report().Sources["reuters"], models.FeedbackStats{Total: 3, Relevant: 2, Irrelevant: 1, Precision: 1, Usefulness: 2.0 / 3}},
+		{"bloomberg", s.Report().Sources["bloomberg"], models.FeedbackStats{Total: 1, WrongCategory: 1, Precision: 0, Usefulness: 0}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestOpenRestoresVotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "feedback.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record(vote(1, "A1", "h1", "finance", "reuters", models.FeedbackRelevant)); err != nil {
+		t.Fatal(err)
+	}
+	s.Record(vote(2, "A1", "h1", "finance", "reuters", models.FeedbackIrrelevant))
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Report().Overall; got.Total != 2 || got.Relevant != 1 || got.Irrelevant != 1 {
+		t.Errorf("reopened store has %+v", got)
+	}
+}
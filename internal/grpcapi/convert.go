@@ -0,0 +1,67 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	newsv1 "github.com/ObiAU/hfnewsaggregator/internal/pb/newsv1"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toQuery(filter *newsv1.ArticleFilter) cache.Query {
+	if filter == nil {
+		return cache.Query{}
+	}
+	return cache.Query{
+		Category:      rules.CanonicalCategory(filter.GetCategory()),
+		Source:        filter.GetSource(),
+		Tags:          filter.GetTags(),
+		Sentiment:     filter.GetSentiment(),
+		MinConfidence: filter.GetMinConfidence(),
+		Since:         fromTimestamp(filter.GetSince()),
+		Until:         fromTimestamp(filter.GetUntil()),
+		Text:          filter.GetQuery(),
+	}
+}
+
+// toProto converts article; eventID is only set on Subscribe messages.
+func toProto(article models.CategorizedArticle, eventID string) *newsv1.CategorizedArticle {
+	return &newsv1.CategorizedArticle{
+		Article: &newsv1.Article{
+			Id:          article.ID,
+			Title:       article.Title,
+			Content:     article.Content,
+			FullText:    article.FullText,
+			Url:         article.URL,
+			Source:      article.Source,
+			PublishedAt: toTimestamp(article.PublishedAt),
+			Hash:        article.Hash,
+			Category:    article.Category,
+			Tags:        article.Tags,
+			Sentiment:   article.Sentiment,
+			Summary:     article.Summary,
+			Language:    article.Language,
+			Metadata:    article.Metadata,
+		},
+		Confidence:  article.Confidence,
+		Urgency:     article.Urgency,
+		ProcessedAt: toTimestamp(article.ProcessedAt),
+		EventId:     eventID,
+	}
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
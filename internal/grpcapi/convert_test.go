@@ -0,0 +1,86 @@
+package grpcapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	newsv1 "github.com/ObiAU/hfnewsaggregator/internal/pb/newsv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestToQuery(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter *newsv1.ArticleFilter
+		want   cache.Query
+	}{
+		{"nil", nil, cache.Query{}},
+		{"empty", &newsv1.ArticleFilter{}, cache.Query{}},
+		{"category alias", &newsv1.ArticleFilter{Category: "crypto"}, cache.Query{Category: "cryptocurrency"}},
+		{
+			"every field",
+			&newsv1.ArticleFilter{
+				Source:        "reuters",
+				Tags:          []string{"etf", "sec"},
+				Sentiment:     "negative",
+				MinConfidence: 0.7,
+				Query:         "rate cut",
+				Since:         timestamppb.New(since),
+			},
+			cache.Query{Source: "reuters", Tags: []string{"etf", "sec"}, Sentiment: "negative", MinConfidence: 0.7, Text: "rate cut", Since: since},
+		},
+	}
+	for _, tt := range tests {
+		got := toQuery(tt.filter)
+		if !got.Since.Equal(tt.want.Since) {
+			t.Errorf("%s: since = %v, want %v", tt.name, got.Since, tt.want.Since)
+		}
+		got.Since, tt.want.Since = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: toQuery = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToProto(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	article := models.CategorizedArticle{
+		Article: models.Article{
+			Title:       "ETF approved",
+			URL:         "https://example.com/etf",
+			PublishedAt: published,
+			Hash:        "abc123",
+			Tags:        []string{"etf"},
+			Metadata:    map[string]string{"source_name": "Reuters"},
+		},
+		Confidence: 0.9,
+	}
+
+	tests := []struct {
+		name    string
+		eventID string
+	}{
+		{"list", ""},
+		{"subscribe", "1714564800000000000-abc123"},
+	}
+	for _, tt := range tests {
+		got := toProto(article, tt.eventID)
+		switch {
+		case got.GetEventId() != tt.eventID:
+			t.Errorf("%s: event_id = %q", tt.name, got.GetEventId())
+		case got.GetArticle().GetTitle() != article.Title || got.GetArticle().GetUrl() != article.URL || got.GetConfidence() != 0.9:
+			t.Errorf("%s: fields not copied: %v", tt.name, got)
+		case !got.GetArticle().GetPublishedAt().AsTime().Equal(published):
+			t.Errorf("%s: published_at = %v", tt.name, got.GetArticle().GetPublishedAt())
+		case got.GetProcessedAt() != nil:
+			t.Errorf("%s: zero processed_at sent as %v, want unset", tt.name, got.GetProcessedAt())
+		case got.GetArticle().GetMetadata()["source_name"] != "Reuters":
+			t.Errorf("%s: metadata = %v", tt.name, got.GetArticle().GetMetadata())
+		}
+	}
+}
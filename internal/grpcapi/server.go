@@ -0,0 +1,190 @@
+// Package grpcapi serves the NewsService defined in proto/news/v1/news.proto.
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/ObiAU/hfnewsaggregator --go-grpc_out=../.. --go-grpc_opt=module=github.com/ObiAU/hfnewsaggregator news/v1/news.proto
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/apikey"
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	newsv1 "github.com/ObiAU/hfnewsaggregator/internal/pb/newsv1"
+	"github.com/ObiAU/hfnewsaggregator/internal/stream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Server implements NewsService over the article cache and the live feed hub.
+// Every call needs one of the configured API keys.
+type Server struct {
+	newsv1.UnimplementedNewsServiceServer
+
+	keys  apikey.Keys
+	cache *cache.Cache
+	hub   *stream.Hub
+}
+
+func New(keys []string, cacheLayer *cache.Cache, hub *stream.Hub) *Server {
+	return &Server{
+		keys:  apikey.New(keys),
+		cache: cacheLayer,
+		hub:   hub,
+	}
+}
+
+// GRPCServer returns a grpc.Server with the service registered. Server pings
+// keep idle Subscribe streams alive through proxies.
+func (s *Server) GRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.authenticateUnary),
+		grpc.StreamInterceptor(s.authenticateStream),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    30 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             15 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
+	newsv1.RegisterNewsServiceServer(server, s)
+	return server
+}
+
+func (s *Server) GetArticle(ctx context.Context, req *newsv1.GetArticleRequest) (*newsv1.CategorizedArticle, error) {
+	if len(req.GetId()) < 6 {
+		return nil, status.Error(codes.InvalidArgument, "id must be an article hash or a prefix of at least 6 characters")
+	}
+
+	article, ok := s.cache.FindCategorized(req.GetId())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "article not found")
+	}
+	return toProto(article, ""), nil
+}
+
+// ListArticles pages through cached categorized articles, newest first. The
+// page token is the offset of the next page, and page_size is capped at 100.
+func (s *Server) ListArticles(ctx context.Context, req *newsv1.ListArticlesRequest) (*newsv1.ListArticlesResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	articles := s.cache.QueryCategorized(toQuery(req.GetFilter()))
+	resp := &newsv1.ListArticlesResponse{Total: int32(len(articles))}
+	if offset < len(articles) {
+		end := offset + pageSize
+		if end < len(articles) {
+			resp.NextPageToken = strconv.Itoa(end)
+		} else {
+			end = len(articles)
+		}
+		for _, article := range articles[offset:end] {
+			resp.Articles = append(resp.Articles, toProto(article, ""))
+		}
+	}
+	return resp, nil
+}
+
+// Subscribe streams categorized articles matching the filter as they are
+// processed. With last_event_id set it first replays what the client missed.
+func (s *Server) Subscribe(req *newsv1.SubscribeRequest, srv newsv1.NewsService_SubscribeServer) error {
+	feed, err := s.hub.Open(toQuery(req.GetFilter()), req.GetLastEventId())
+	switch {
+	case errors.Is(err, stream.ErrInvalidEventID):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, stream.ErrTooManySubscribers):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer feed.Close()
+
+	send := func(article models.CategorizedArticle) error {
+		id, ok := feed.Next(article)
+		if !ok {
+			return nil
+		}
+		return srv.Send(toProto(article, id))
+	}
+
+	if err := feed.Replay(send); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return srv.Context().Err()
+		case article, ok := <-feed.Events():
+			if !ok {
+				return status.Error(codes.Unavailable, "live feed closed")
+			}
+			if err := send(article); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) authenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) authenticateStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize accepts the key as "authorization: Bearer <key>" or in the
+// x-api-key metadata, matching the REST API.
+func (s *Server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var key string
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		key = values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if bearer, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+			key = strings.TrimSpace(bearer)
+		}
+	}
+
+	if !s.keys.Valid(key) {
+		return status.Error(codes.Unauthenticated, "missing or invalid API key")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	newsv1 "github.com/ObiAU/hfnewsaggregator/internal/pb/newsv1"
+	"github.com/ObiAU/hfnewsaggregator/internal/stream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testKey = "secret"
+
+// newTestClient serves the API over an in-memory listener.
+func newTestClient(t *testing.T, articles *cache.Cache) newsv1.NewsServiceClient {
+	t.Helper()
+
+	hub := stream.NewHub(articles)
+	server := New([]string{testKey}, articles, hub).GRPCServer()
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Stop()
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return newsv1.NewNewsServiceClient(conn)
+}
+
+func testCache(t *testing.T, n int) *cache.Cache {
+	t.Helper()
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < n; i++ {
+		articles.AddCategorizedArticle(models.CategorizedArticle{
+			Article: models.Article{
+				Hash:        fmt.Sprintf("hash%d00000", i),
+				Category:    "technology",
+				PublishedAt: base.Add(time.Duration(i) * time.Minute),
+			},
+			ProcessedAt: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return articles
+}
+
+func TestAuthorization(t *testing.T) {
+	client := newTestClient(t, testCache(t, 1))
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want codes.Code
+	}{
+		{"no key", nil, codes.Unauthenticated},
+		{"wrong key", metadata.Pairs("x-api-key", "nope"), codes.Unauthenticated},
+		{"x-api-key", metadata.Pairs("x-api-key", testKey), codes.OK},
+		{"bearer", metadata.Pairs("authorization", "Bearer "+testKey), codes.OK},
+		{"bearer wins over x-api-key", metadata.Pairs("authorization", "Bearer nope", "x-api-key", testKey), codes.Unauthenticated},
+		{"not bearer", metadata.Pairs("authorization", testKey), codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if tt.md != nil {
+			ctx = metadata.NewOutgoingContext(ctx, tt.md)
+		}
+
+		_, err := client.GetArticle(ctx, &newsv1.GetArticleRequest{Id: "hash000000"})
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: GetArticle code = %v, want %v", tt.name, got, tt.want)
+		}
+
+		// Stream calls are checked before the handler runs, so the error
+		// arrives with the first Recv.
+		sub, err := client.Subscribe(ctx, &newsv1.SubscribeRequest{LastEventId: stream.Cursor{ProcessedAt: time.Unix(0, 1), Hash: "0"}.EventID()})
+		if err == nil {
+			_, err = sub.Recv()
+		}
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: Subscribe code = %v, want %v", tt.name, got, tt.want)
+		}
+		cancel()
+	}
+}
+
+func TestListArticlesPaging(t *testing.T) {
+	client := newTestClient(t, testCache(t, 5))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", testKey)
+
+	tests := []struct {
+		req    *newsv1.ListArticlesRequest
+		code   codes.Code
+		hashes []string
+		next   string
+	}{
+		{&newsv1.ListArticlesRequest{PageSize: 2}, codes.OK, []string{"hash400000", "hash300000"}, "2"},
+		{&newsv1.ListArticlesRequest{PageSize: 2, PageToken: "4"}, codes.OK, []string{"hash000000"}, ""},
+		{&newsv1.ListArticlesRequest{Filter: &newsv1.ArticleFilter{Category: "sports"}}, codes.OK, nil, ""},
+		{&newsv1.ListArticlesRequest{PageSize: 1000}, codes.OK, []string{"hash400000", "hash300000", "hash200000", "hash100000", "hash000000"}, ""},
+		{&newsv1.ListArticlesRequest{PageSize: -1}, codes.InvalidArgument, nil, ""},
+		{&newsv1.ListArticlesRequest{PageToken: "x"}, codes.InvalidArgument, nil, ""},
+	}
+	for _, tt := range tests {
+		resp, err := client.ListArticles(ctx, tt.req)
+		if got := status.Code(err); got != tt.code {
+			t.Errorf("%v: code = %v, want %v", tt.req, got, tt.code)
+			continue
+		}
+		var hashes []string
+		for _, article := range resp.GetArticles() {
+			hashes = append(hashes, article.GetArticle().GetHash())
+		}
+		if fmt.Sprint(hashes) != fmt.Sprint(tt.hashes) || resp.GetNextPageToken() != tt.next {
+			t.Errorf("%v: got %v (next %q), want %v (next %q)", tt.req, hashes, resp.GetNextPageToken(), tt.hashes, tt.next)
+		}
+	}
+}
+
+func TestListArticlesCapsPageSize(t *testing.T) {
+	client := newTestClient(t, testCache(t, maxPageSize+5))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", testKey)
+
+	resp, err := client.ListArticles(ctx, &newsv1.ListArticlesRequest{PageSize: maxPageSize + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetArticles()) != maxPageSize || resp.GetNextPageToken() != fmt.Sprint(maxPageSize) {
+		t.Errorf("got %d articles (next %q), want %d", len(resp.GetArticles()), resp.GetNextPageToken(), maxPageSize)
+	}
+}
+
+func TestSubscribeResumes(t *testing.T) {
+	articles := testCache(t, 3)
+	client := newTestClient(t, articles)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", testKey)
+
+	first, _ := articles.GetCategorizedArticle("hash000000")
+	sub, err := client.Subscribe(ctx, &newsv1.SubscribeRequest{LastEventId: stream.CursorOf(first).EventID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"hash100000", "hash200000"} {
+		msg, err := sub.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.GetArticle().GetHash() != want || msg.GetEventId() == "" {
+			t.Errorf("replayed %q (event %q), want %q", msg.GetArticle().GetHash(), msg.GetEventId(), want)
+		}
+	}
+
+	bad, err := client.Subscribe(ctx, &newsv1.SubscribeRequest{LastEventId: "nonsense"})
+	if err == nil {
+		_, err = bad.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("bad last_event_id: %v", err)
+	}
+}
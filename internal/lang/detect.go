@@ -0,0 +1,153 @@
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+const Unknown = "und"
+
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "for", "on", "with", "as", "by", "at", "from", "that", "this", "are", "was", "will", "after", "over"},
+	"es": {"el", "la", "los", "las", "de", "del", "y", "en", "que", "por", "para", "con", "una", "un", "es", "se", "su", "al", "más", "sobre"},
+	"pt": {"o", "a", "os", "as", "de", "do", "da", "dos", "das", "e", "em", "que", "para", "com", "um", "uma", "no", "na", "não", "mais"},
+	"fr": {"le", "la", "les", "de", "des", "du", "et", "en", "un", "une", "est", "pour", "que", "qui", "dans", "sur", "au", "aux", "par", "pas"},
+	"de": {"der", "die", "das", "und", "in", "den", "von", "zu", "mit", "ist", "auf", "für", "im", "dem", "nicht", "ein", "eine", "auch", "sich", "nach"},
+	"it": {"il", "lo", "la", "gli", "le", "di", "del", "della", "e", "che", "per", "con", "un", "una", "è", "non", "sono", "nel", "sulla", "alla"},
+	"nl": {"de", "het", "een", "en", "van", "in", "op", "te", "dat", "voor", "met", "is", "niet", "zijn", "bij", "ook", "naar", "om", "aan", "wordt"},
+	"tr": {"ve", "bir", "bu", "için", "ile", "da", "de", "olarak", "daha", "çok", "gibi", "en", "ne", "sonra", "kadar", "olan", "ise", "mi", "değil", "yeni"},
+	"id": {"dan", "yang", "di", "ke", "dari", "untuk", "dengan", "ini", "itu", "pada", "akan", "dalam", "tidak", "adalah", "oleh", "juga", "bisa", "telah", "baru", "harga"},
+}
+
+// Detect returns an ISO 639-1 code for text, or Unknown when there is not
+// enough signal. Non-Latin scripts are identified by their Unicode script;
+// Latin-script languages are told apart by common stopwords.
+func Detect(text string) string {
+	if lang := detectScript(text); lang != "" {
+		return lang
+	}
+	return detectLatin(text)
+}
+
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		}
+	}
+
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese text mixes kana with Han characters; any meaningful amount of
+	// kana means the Han characters belong to Japanese rather than Chinese.
+	if counts["ja"] > 0 && counts["ja"]*5 >= counts["zh"] {
+		counts["ja"] += counts["zh"]
+		counts["zh"] = 0
+	}
+
+	best, bestCount := "", 0
+	for lang, count := range counts {
+		if count > bestCount {
+			best, bestCount = lang, count
+		}
+	}
+
+	if best == "latin" || bestCount*2 < letters {
+		return ""
+	}
+	return best
+}
+
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		return Unknown
+	}
+
+	scores := make(map[string]int)
+	for _, word := range words {
+		for lang, list := range stopwords {
+			for _, stopword := range list {
+				if word == stopword {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestScore, runnerUp := Unknown, 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			runnerUp = bestScore
+			best, bestScore = lang, score
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+
+	if bestScore == 0 || bestScore == runnerUp {
+		return Unknown
+	}
+	return best
+}
+
+func Name(code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
+
+var names = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"pt": "Portuguese",
+	"fr": "French",
+	"de": "German",
+	"it": "Italian",
+	"nl": "Dutch",
+	"tr": "Turkish",
+	"id": "Indonesian",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"ko": "Korean",
+	"ru": "Russian",
+	"ar": "Arabic",
+	"he": "Hebrew",
+	"el": "Greek",
+	"th": "Thai",
+	"hi": "Hindi",
+}
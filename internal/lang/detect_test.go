@@ -0,0 +1,37 @@
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The central bank will hold rates after the meeting on Tuesday", "en"},
+		{"El banco central mantiene las tasas de interés para los próximos meses", "es"},
+		{"Le gouvernement a annoncé des mesures pour les entreprises dans la région", "fr"},
+		{"Die Regierung hat sich mit den Ländern auf einen neuen Plan für die Bahn geeinigt", "de"},
+		{"Биткоин вырос выше семидесяти тысяч долларов", "ru"},
+		{"ビットコインの価格が過去最高を更新した", "ja"},
+		{"比特币价格创下历史新高", "zh"},
+		{"비트코인 가격이 사상 최고치를 기록했다", "ko"},
+		{"Bitcoin ETF", Unknown},
+		{"", Unknown},
+		{"12345 !!!", Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name("pt"); got != "Portuguese" {
+		t.Errorf("Name(pt) = %q", got)
+	}
+	if got := Name("xx"); got != "xx" {
+		t.Errorf("unknown codes should be returned as is, got %q", got)
+	}
+}
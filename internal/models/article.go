@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -9,6 +10,7 @@ type Article struct {
 	ID          string            `json:"id"`
 	Title       string            `json:"title"`
 	Content     string            `json:"content"`
+	FullText    string            `json:"full_text,omitempty"`
 	URL         string            `json:"url"`
 	Source      string            `json:"source"`
 	PublishedAt time.Time         `json:"published_at"`
@@ -17,6 +19,7 @@ type Article struct {
 	Tags        []string          `json:"tags"`
 	Sentiment   string            `json:"sentiment"`
 	Summary     string            `json:"summary"`
+	Language    string            `json:"language,omitempty"`
 	Metadata    map[string]string `json:"metadata"`
 }
 
@@ -28,14 +31,141 @@ type NewsSource interface {
 type CategorizedArticle struct {
 	Article
 	Confidence  float64   `json:"confidence"`
+	Urgency     float64   `json:"urgency"`
 	ProcessedAt time.Time `json:"processed_at"`
 }
 
+// UserSettings are per chat: a private chat's ID is the user's ID, while a
+// group or channel shares one set of settings between its members.
+type UserSettings struct {
+	ChatID          int64   `json:"chat_id"`
+	Timezone        string  `json:"timezone,omitempty"`
+	QuietStart      string  `json:"quiet_start,omitempty"`
+	QuietEnd        string  `json:"quiet_end,omitempty"`
+	UrgentThreshold float64 `json:"urgent_threshold,omitempty"`
+	AlertTemplate   string  `json:"alert_template,omitempty"`
+	Disabled        bool    `json:"disabled,omitempty"`
+	DisabledReason  string  `json:"disabled_reason,omitempty"`
+}
+
 type UserAlert struct {
-	UserID     int64    `json:"user_id"`
-	ChatID     int64    `json:"chat_id"`
-	Keywords   []string `json:"keywords"`
-	Categories []string `json:"categories"`
-	Tags       []string `json:"tags"`
-	Enabled    bool     `json:"enabled"`
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	UserID         int64         `json:"user_id"`
+	ChatID         int64         `json:"chat_id"`
+	Keywords       []string      `json:"keywords"`
+	Categories     []string      `json:"categories"`
+	Tags           []string      `json:"tags"`
+	Topic          string        `json:"topic,omitempty"`
+	TopicEmbedding []float64     `json:"topic_embedding,omitempty"`
+	TopicThreshold float64       `json:"topic_threshold,omitempty"`
+	Languages      []string      `json:"languages,omitempty"`
+	Rule           string        `json:"rule,omitempty"`
+	MinConfidence  float64       `json:"min_confidence,omitempty"`
+	Sentiments     []string      `json:"sentiments,omitempty"`
+	Sources        []string      `json:"sources,omitempty"`
+	ExcludeSources []string      `json:"exclude_sources,omitempty"`
+	MaxAge         time.Duration `json:"max_age,omitempty"`
+	Delivery       string        `json:"delivery,omitempty"`
+	DigestAt       string        `json:"digest_at,omitempty"`
+	DigestOverview bool          `json:"digest_overview,omitempty"`
+	LastDigestAt   time.Time     `json:"last_digest_at,omitempty"`
+	Enabled        bool          `json:"enabled"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+const (
+	DeliveryInstant = "instant"
+	DeliveryHourly  = "hourly"
+	DeliveryDaily   = "daily"
+)
+
+// Matches reports whether ref names this alert by ID or (case-insensitive) name.
+func (a *UserAlert) Matches(ref string) bool {
+	return strings.EqualFold(a.ID, ref) || strings.EqualFold(a.Name, ref)
+}
+
+type Delivery struct {
+	ID          string    `json:"id"`
+	UserID      int64     `json:"user_id"`
+	ChatID      int64     `json:"chat_id"`
+	MessageID   int       `json:"message_id"`
+	AlertID     string    `json:"alert_id"`
+	AlertName   string    `json:"alert_name"`
+	ArticleHash string    `json:"article_hash"`
+	Category    string    `json:"category"`
+	Source      string    `json:"source"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+const (
+	FeedbackRelevant      = "relevant"
+	FeedbackIrrelevant    = "irrelevant"
+	FeedbackWrongCategory = "wrong_category"
+)
+
+type Feedback struct {
+	UserID      int64     `json:"user_id"`
+	AlertID     string    `json:"alert_id"`
+	ArticleHash string    `json:"article_hash"`
+	Category    string    `json:"category"`
+	Source      string    `json:"source"`
+	Verdict     string    `json:"verdict"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Answer is an LLM reply to a user question. Citations are indexes into the
+// articles the answer was generated from.
+type Answer struct {
+	Text      string `json:"text"`
+	Citations []int  `json:"citations"`
+}
+
+type AIUsage struct {
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	EmbeddingTokens  int64     `json:"embedding_tokens"`
+	EstimatedCostUSD float64   `json:"estimated_cost_usd"`
+	Since            time.Time `json:"since"`
+}
+
+type SourceHealth struct {
+	Name        string    `json:"name"`
+	Paused      bool      `json:"paused"`
+	Fetches     int       `json:"fetches"`
+	Failures    int       `json:"failures"`
+	Articles    int       `json:"articles"`
+	LastFetch   time.Time `json:"last_fetch"`
+	LastSuccess time.Time `json:"last_success"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// EmailSubscriber receives alerts by email, either one message per article
+// or a daily digest sent at DigestAt (UTC).
+type EmailSubscriber struct {
+	Address       string    `json:"address"`
+	Delivery      string    `json:"delivery"`
+	DigestAt      string    `json:"digest_at,omitempty"`
+	Categories    []string  `json:"categories,omitempty"`
+	MinConfidence float64   `json:"min_confidence,omitempty"`
+	Rule          string    `json:"rule,omitempty"`
+	Token         string    `json:"token"`
+	LastDigestAt  time.Time `json:"last_digest_at,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type FeedbackStats struct {
+	Total         int     `json:"total"`
+	Relevant      int     `json:"relevant"`
+	Irrelevant    int     `json:"irrelevant"`
+	WrongCategory int     `json:"wrong_category"`
+	Precision     float64 `json:"precision"`
+	Usefulness    float64 `json:"usefulness"`
+}
+
+type FeedbackReport struct {
+	Overall    FeedbackStats            `json:"overall"`
+	Categories map[string]FeedbackStats `json:"categories"`
+	Sources    map[string]FeedbackStats `json:"sources"`
 }
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// Discord embed limits.
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordFieldLimit       = 1024
+)
+
+var sentimentColors = map[string]int{
+	"positive": 0x2ecc71,
+	"negative": 0xe74c3c,
+	"neutral":  0x95a5a6,
+}
+
+// Discord posts articles to a Discord channel webhook as embeds.
+type Discord struct {
+	name   string
+	url    string
+	filter Filter
+	client *http.Client
+}
+
+type discordMessage struct {
+	Username        string                 `json:"username"`
+	Embeds          []discordEmbed         `json:"embeds"`
+	AllowedMentions map[string]interface{} `json:"allowed_mentions"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+func NewDiscord(name, url string, filter Filter) *Discord {
+	return &Discord{
+		name:   name,
+		url:    url,
+		filter: filter,
+		client: newHTTPClient(),
+	}
+}
+
+func (d *Discord) Name() string {
+	return d.name
+}
+
+func (d *Discord) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	if !d.filter.Matches(article) {
+		return nil
+	}
+
+	body, err := json.Marshal(discordPayload(article))
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, d.client, d.url, body, nil)
+}
+
+func discordPayload(article models.CategorizedArticle) discordMessage {
+	embed := discordEmbed{
+		Title:       truncate(article.Title, discordTitleLimit),
+		Description: truncate(article.Summary, discordDescriptionLimit),
+		Color:       sentimentColors[article.Sentiment],
+	}
+	if source := articleSource(article); source != "" {
+		embed.Footer = &discordFooter{Text: source}
+	}
+	if validURL(article.URL) {
+		embed.URL = article.URL
+	}
+	if !article.PublishedAt.IsZero() {
+		embed.Timestamp = article.PublishedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	fields := []discordField{
+		{Name: "Category", Value: article.Category, Inline: true},
+		{Name: "Sentiment", Value: article.Sentiment, Inline: true},
+		{Name: "Confidence", Value: fmt.Sprintf("%.0f%%", article.Confidence*100), Inline: true},
+	}
+	if len(article.Tags) > 0 {
+		fields = append(fields, discordField{Name: "Tags", Value: strings.Join(article.Tags, ", ")})
+	}
+	for _, field := range fields {
+		if field.Value != "" {
+			field.Value = truncate(field.Value, discordFieldLimit)
+			embed.Fields = append(embed.Fields, field)
+		}
+	}
+
+	// Article text is untrusted, so never let it ping @everyone or roles.
+	return discordMessage{
+		Username:        "HF News",
+		Embeds:          []discordEmbed{embed},
+		AllowedMentions: map[string]interface{}{"parse": []string{}},
+	}
+}
@@ -0,0 +1,503 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/fileutil"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+	"github.com/ObiAU/hfnewsaggregator/internal/schedule"
+)
+
+const (
+	defaultDigestAt  = "08:00"
+	maxPendingDigest = 200
+	emailQueueSize   = 256
+)
+
+type EmailConfig struct {
+	Host            string
+	Port            string
+	Username        string
+	Password        string
+	From            string
+	BaseURL         string
+	SubscribersFile string
+}
+
+type emailSubscriber struct {
+	models.EmailSubscriber
+	filter  Filter
+	pending []models.CategorizedArticle
+}
+
+// storedSubscriber is a subscriber as saved to the subscribers file,
+// including the articles waiting for its next digest so a restart doesn't
+// lose them.
+type storedSubscriber struct {
+	models.EmailSubscriber
+	Pending []models.CategorizedArticle `json:"pending,omitempty"`
+}
+
+type instantEmail struct {
+	sub     models.EmailSubscriber
+	article models.CategorizedArticle
+}
+
+// Email sends instant alerts and daily digests over SMTP as multipart
+// text and HTML messages. Every message links to an unsubscribe page
+// served by HandleUnsubscribe. All sending happens in Run.
+type Email struct {
+	config      EmailConfig
+	from        *mail.Address
+	auth        smtp.Auth
+	send        func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	instant     chan instantEmail
+	subscribers []*emailSubscriber
+	dirty       bool
+	mu          sync.Mutex
+}
+
+func NewEmail(config EmailConfig) (*Email, error) {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", config.From, err)
+	}
+	if !validURL(config.BaseURL) {
+		return nil, fmt.Errorf("a public http(s) base URL is needed for unsubscribe links")
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	e := &Email{
+		config:  config,
+		from:    from,
+		send:    smtp.SendMail,
+		instant: make(chan instantEmail, emailQueueSize),
+	}
+	if config.Username != "" {
+		e.auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Email) Name() string {
+	return "email"
+}
+
+// digestCopy keeps only what the digest email shows. Pending digests are
+// saved with the subscribers, so the article and page text and the other
+// metadata are left out.
+func digestCopy(article models.CategorizedArticle) models.CategorizedArticle {
+	name := article.Metadata["source_name"]
+	article.Content = ""
+	article.FullText = ""
+	article.Metadata = nil
+	if name != "" {
+		article.Metadata = map[string]string{"source_name": name}
+	}
+	return article
+}
+
+// Notify queues the article for Run to email to instant subscribers and
+// adds it to daily subscribers' next digest.
+func (e *Email) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	queued := digestCopy(article)
+
+	e.mu.Lock()
+	var instant []models.EmailSubscriber
+	for _, sub := range e.subscribers {
+		if !sub.filter.Matches(article) {
+			continue
+		}
+		if sub.Delivery == models.DeliveryDaily {
+			if len(sub.pending) < maxPendingDigest {
+				sub.pending = append(sub.pending, queued)
+				e.dirty = true
+			}
+			continue
+		}
+		instant = append(instant, sub.EmailSubscriber)
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for _, sub := range instant {
+		select {
+		case e.instant <- instantEmail{sub: sub, article: article}:
+		default:
+			errs = append(errs, fmt.Errorf("%s: email queue is full", sub.Address))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run sends queued instant emails and daily digests as they fall due until
+// ctx is cancelled.
+func (e *Email) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := e.Flush(); err != nil {
+				log.Printf("Failed to save email subscribers: %v", err)
+			}
+			return
+		case email := <-e.instant:
+			e.sendInstant(email)
+		case now := <-ticker.C:
+			e.sendDueDigests(now)
+			if err := e.Flush(); err != nil {
+				log.Printf("Failed to save email subscribers: %v", err)
+			}
+		}
+	}
+}
+
+// Flush saves subscribers if articles were queued for digests since the
+// last save.
+func (e *Email) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.dirty {
+		return nil
+	}
+	return e.saveLocked()
+}
+
+func (e *Email) sendInstant(email instantEmail) {
+	content := emailContent{
+		Intro:    "A new article matched your subscription.",
+		Articles: []emailArticle{newEmailArticle(email.article)},
+	}
+	if err := e.deliver(email.sub, "📰 "+email.article.Title, content); err != nil {
+		log.Printf("Failed to email %q to %s: %v", email.article.Title, email.sub.Address, err)
+	}
+}
+
+func (e *Email) sendDueDigests(now time.Time) {
+	type dueDigest struct {
+		sub      models.EmailSubscriber
+		since    time.Time
+		articles []models.CategorizedArticle
+	}
+
+	e.mu.Lock()
+	var due []dueDigest
+	for _, sub := range e.subscribers {
+		if sub.Delivery != models.DeliveryDaily {
+			continue
+		}
+		scheduled, err := schedule.LastOccurrence(sub.DigestAt, now.UTC())
+		if err != nil || !sub.LastDigestAt.Before(scheduled) {
+			continue
+		}
+
+		if len(sub.pending) > 0 {
+			due = append(due, dueDigest{sub: sub.EmailSubscriber, since: sub.LastDigestAt, articles: sub.pending})
+		}
+		sub.pending = nil
+		sub.LastDigestAt = now
+	}
+	if len(due) > 0 {
+		if err := e.saveLocked(); err != nil {
+			log.Printf("Failed to save email subscribers: %v", err)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, digest := range due {
+		content := emailContent{Intro: "1 article matched your subscription"}
+		if len(digest.articles) > 1 {
+			content.Intro = fmt.Sprintf("%d articles matched your subscription", len(digest.articles))
+		}
+		if !digest.since.IsZero() {
+			content.Intro += " since " + digest.since.UTC().Format("Jan 2 15:04") + " UTC"
+		}
+		content.Intro += "."
+		for _, article := range digest.articles {
+			content.Articles = append(content.Articles, newEmailArticle(article))
+		}
+
+		subject := fmt.Sprintf("🗞 Your daily news digest - %s", now.UTC().Format("Jan 2"))
+		if err := e.deliver(digest.sub, subject, content); err != nil {
+			log.Printf("Failed to email digest to %s: %v", digest.sub.Address, err)
+		}
+	}
+}
+
+func (e *Email) deliver(sub models.EmailSubscriber, subject string, content emailContent) error {
+	content.UnsubscribeURL = e.config.BaseURL + "/unsubscribe?token=" + sub.Token
+
+	msg, err := e.buildMessage(sub.Address, subject, content)
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(e.config.Host, e.config.Port)
+	return e.send(addr, e.auth, e.from.Address, []string{sub.Address}, msg)
+}
+
+// buildMessage renders a multipart/alternative message with quoted-printable
+// text and HTML parts and one-click unsubscribe headers.
+func (e *Email) buildMessage(to, subject string, content emailContent) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		render      func(*bytes.Buffer) error
+	}{
+		{"text/plain; charset=utf-8", func(buf *bytes.Buffer) error { return emailTextTemplate.Execute(buf, content) }},
+		{"text/html; charset=utf-8", func(buf *bytes.Buffer) error { return emailHTMLTemplate.Execute(buf, content) }},
+	}
+	for _, part := range parts {
+		var rendered bytes.Buffer
+		if err := part.render(&rendered); err != nil {
+			return nil, err
+		}
+
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(rendered.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	// Titles come from feeds, so keep them on one line to prevent header
+	// injection.
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", e.from.String()},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", truncate(subject, 200))},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", randomToken(), domain(e.from.Address))},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary())},
+		{"List-Unsubscribe", "<" + content.UnsubscribeURL + ">"},
+		{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", header[0], header[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// Subscribe adds a subscriber, or updates the one with the same address
+// while keeping its unsubscribe token.
+func (e *Email) Subscribe(sub models.EmailSubscriber) (models.EmailSubscriber, error) {
+	address, err := mail.ParseAddress(sub.Address)
+	if err != nil {
+		return sub, fmt.Errorf("invalid email address %q", sub.Address)
+	}
+	sub.Address = address.Address
+
+	switch sub.Delivery {
+	case "":
+		sub.Delivery = models.DeliveryInstant
+	case models.DeliveryInstant, models.DeliveryDaily:
+	default:
+		return sub, fmt.Errorf("delivery must be instant or daily, got %q", sub.Delivery)
+	}
+	if sub.Delivery == models.DeliveryDaily && sub.DigestAt == "" {
+		sub.DigestAt = defaultDigestAt
+	}
+	if sub.DigestAt != "" {
+		if _, err := time.Parse("15:04", sub.DigestAt); err != nil {
+			return sub, fmt.Errorf("at must be a time like 08:00, got %q", sub.DigestAt)
+		}
+	}
+	for i, category := range sub.Categories {
+		sub.Categories[i] = rules.CanonicalCategory(category)
+	}
+
+	subscriber := &emailSubscriber{EmailSubscriber: sub}
+	if err := subscriber.compile(); err != nil {
+		return sub, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	subscriber.Token = randomToken()
+	subscriber.CreatedAt = time.Now()
+	subscriber.LastDigestAt = time.Now()
+	replaced := false
+	for i, existing := range e.subscribers {
+		if strings.EqualFold(existing.Address, sub.Address) {
+			subscriber.Token = existing.Token
+			subscriber.CreatedAt = existing.CreatedAt
+			subscriber.LastDigestAt = existing.LastDigestAt
+			subscriber.pending = existing.pending
+			e.subscribers[i] = subscriber
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		e.subscribers = append(e.subscribers, subscriber)
+	}
+
+	return subscriber.EmailSubscriber, e.saveLocked()
+}
+
+// Unsubscribe removes the subscriber with the given address, reporting
+// whether there was one.
+func (e *Email) Unsubscribe(address string) (bool, error) {
+	return e.remove(func(sub *emailSubscriber) bool {
+		return strings.EqualFold(sub.Address, address)
+	})
+}
+
+func (e *Email) Subscribers() []models.EmailSubscriber {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	subscribers := make([]models.EmailSubscriber, len(e.subscribers))
+	for i, sub := range e.subscribers {
+		subscribers[i] = sub.EmailSubscriber
+	}
+	return subscribers
+}
+
+func (e *Email) remove(match func(*emailSubscriber) bool) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, sub := range e.subscribers {
+		if match(sub) {
+			e.subscribers = append(e.subscribers[:i:i], e.subscribers[i+1:]...)
+			return true, e.saveLocked()
+		}
+	}
+	return false, nil
+}
+
+// HandleUnsubscribe serves the link in every email. GET only shows a
+// confirmation button, so mail scanners that follow links don't remove
+// anyone; POST, which is also what one-click List-Unsubscribe sends, does.
+func (e *Email) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	switch r.Method {
+	case http.MethodGet:
+		unsubscribePage.Execute(w, unsubscribeView{Confirm: true, Token: token})
+	case http.MethodPost:
+		removed, err := e.remove(func(sub *emailSubscriber) bool {
+			return token != "" && sub.Token == token
+		})
+		if err != nil {
+			log.Printf("Failed to save email subscribers: %v", err)
+		}
+		unsubscribePage.Execute(w, unsubscribeView{Removed: removed})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *emailSubscriber) compile() error {
+	s.filter = Filter{Rule: s.Rule, Categories: s.Categories, MinConfidence: s.MinConfidence}
+	return s.filter.compile()
+}
+
+func (e *Email) load() error {
+	data, err := os.ReadFile(e.config.SubscribersFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var subscribers []storedSubscriber
+	if err := json.Unmarshal(data, &subscribers); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", e.config.SubscribersFile, err)
+	}
+
+	for _, sub := range subscribers {
+		subscriber := &emailSubscriber{EmailSubscriber: sub.EmailSubscriber, pending: sub.Pending}
+		if err := subscriber.compile(); err != nil {
+			return fmt.Errorf("subscriber %s: %w", sub.Address, err)
+		}
+		e.subscribers = append(e.subscribers, subscriber)
+	}
+	return nil
+}
+
+func (e *Email) saveLocked() error {
+	if e.config.SubscribersFile == "" {
+		return nil
+	}
+
+	subscribers := make([]storedSubscriber, len(e.subscribers))
+	for i, sub := range e.subscribers {
+		subscribers[i] = storedSubscriber{EmailSubscriber: sub.EmailSubscriber, Pending: sub.pending}
+	}
+
+	data, err := json.MarshalIndent(subscribers, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteAtomic(e.config.SubscribersFile, data); err != nil {
+		return err
+	}
+	e.dirty = false
+	return nil
+}
+
+func randomToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func domain(address string) string {
+	if _, host, ok := strings.Cut(address, "@"); ok {
+		return host
+	}
+	return "localhost"
+}
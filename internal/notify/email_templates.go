@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+type emailContent struct {
+	Intro          string
+	Articles       []emailArticle
+	UnsubscribeURL string
+}
+
+type emailArticle struct {
+	Title      string
+	URL        string
+	Summary    string
+	Category   string
+	Sentiment  string
+	Confidence string
+	Source     string
+	Tags       string
+}
+
+func newEmailArticle(article models.CategorizedArticle) emailArticle {
+	item := emailArticle{
+		Title:      article.Title,
+		Summary:    article.Summary,
+		Category:   article.Category,
+		Sentiment:  article.Sentiment,
+		Confidence: fmt.Sprintf("%.0f%%", article.Confidence*100),
+		Source:     articleSource(article),
+		Tags:       strings.Join(article.Tags, ", "),
+	}
+	if validURL(article.URL) {
+		item.URL = article.URL
+	}
+	return item
+}
+
+var emailTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(`{{.Intro}}
+{{range .Articles}}
+{{.Title}}
+{{.Category}} · {{.Sentiment}} · {{.Confidence}} confidence{{if .Source}} · {{.Source}}{{end}}
+{{if .Summary}}{{.Summary}}
+{{end}}{{if .URL}}{{.URL}}
+{{end}}{{end}}
+--
+Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 640px; margin: 0 auto; color: #222;">
+<p>{{.Intro}}</p>
+{{range .Articles}}
+<div style="border-top: 1px solid #ddd; padding: 12px 0;">
+<h3 style="margin: 0 0 4px;">{{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h3>
+<p style="margin: 0 0 8px; color: #666; font-size: 13px;">{{.Category}} · {{.Sentiment}} · {{.Confidence}} confidence{{if .Source}} · {{.Source}}{{end}}{{if .Tags}} · {{.Tags}}{{end}}</p>
+{{if .Summary}}<p style="margin: 0;">{{.Summary}}</p>{{end}}
+</div>
+{{end}}
+<p style="border-top: 1px solid #ddd; padding-top: 12px; color: #999; font-size: 12px;">
+You are receiving this because you subscribed to HF News Aggregator alerts. <a href="{{.UnsubscribeURL}}">Unsubscribe</a>
+</p>
+</body>
+</html>
+`))
+
+type unsubscribeView struct {
+	Confirm bool
+	Token   string
+	Removed bool
+}
+
+var unsubscribePage = htmltemplate.Must(htmltemplate.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 480px; margin: 40px auto;">
+{{if .Confirm}}
+<p>Stop receiving HF News Aggregator emails?</p>
+<form method="post" action="?token={{.Token}}"><button type="submit">Unsubscribe</button></form>
+{{else if .Removed}}
+<p>You have been unsubscribed.</p>
+{{else}}
+<p>This unsubscribe link is invalid or has already been used.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// fakeSMTP stands in for the mail server behind Email.send.
+type fakeSMTP struct {
+	mu       sync.Mutex
+	messages []sentMail
+}
+
+type sentMail struct {
+	addr string
+	from string
+	to   []string
+	msg  []byte
+}
+
+func (f *fakeSMTP) send(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, sentMail{addr: addr, from: from, to: to, msg: msg})
+	return nil
+}
+
+func (f *fakeSMTP) sent() []sentMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMail(nil), f.messages...)
+}
+
+func newTestEmail(t *testing.T, path string) (*Email, *fakeSMTP) {
+	t.Helper()
+
+	e, err := NewEmail(EmailConfig{
+		Host:            "smtp.test",
+		Port:            "2525",
+		From:            "HF News <news@example.com>",
+		BaseURL:         "https://news.example.com/",
+		SubscribersFile: path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTP{}
+	e.send = server.send
+	return e, server
+}
+
+// readMail parses a sent message and returns its headers and the decoded
+// text and HTML parts.
+func readMail(t *testing.T, raw []byte) (mail.Header, string, string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []string
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		parts = append(parts, string(body))
+	}
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want text and HTML", len(parts))
+	}
+	return msg.Header, parts[0], parts[1]
+}
+
+func waitForMail(t *testing.T, server *fakeSMTP, n int) []sentMail {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(server.sent()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d emails, want %d", len(server.sent()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return server.sent()
+}
+
+func TestEmailInstantIsSentFromRun(t *testing.T) {
+	e, server := newTestEmail(t, "")
+	sub, err := e.Subscribe(models.EmailSubscriber{Address: "Reader <reader@example.org>", Categories: []string{"crypto"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	article := testArticle()
+	article.Title = "Bitcoin <ETF>\r\nBcc: victim@example.org"
+	if err := e.Notify(context.Background(), article); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.sent()) != 0 {
+		t.Fatal("Notify sent the email itself instead of queueing it")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	sent := waitForMail(t, server, 1)[0]
+	if sent.addr != "smtp.test:2525" || sent.from != "news@example.com" || len(sent.to) != 1 || sent.to[0] != "reader@example.org" {
+		t.Errorf("envelope = %s %s %v", sent.addr, sent.from, sent.to)
+	}
+
+	header, text, html := readMail(t, sent.msg)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if subject != "📰 Bitcoin <ETF> Bcc: victim@example.org" || header.Get("Bcc") != "" {
+		t.Errorf("subject = %q, bcc = %q", subject, header.Get("Bcc"))
+	}
+	unsubscribe := "https://news.example.com/unsubscribe?token=" + sub.Token
+	if header.Get("List-Unsubscribe") != "<"+unsubscribe+">" || !strings.Contains(text, unsubscribe) {
+		t.Errorf("unsubscribe link missing: %q", header.Get("List-Unsubscribe"))
+	}
+	if !strings.Contains(html, "Bitcoin &lt;ETF&gt;") || strings.Contains(html, "<ETF>") {
+		t.Error("title was not escaped in the HTML part")
+	}
+	if !strings.Contains(text, "80% confidence · Reuters") {
+		t.Errorf("text part = %q", text)
+	}
+}
+
+func TestEmailFiltersSubscribers(t *testing.T) {
+	e, server := newTestEmail(t, "")
+	e.Subscribe(models.EmailSubscriber{Address: "sports@example.org", Categories: []string{"sports"}})
+	e.Subscribe(models.EmailSubscriber{Address: "all@example.org"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	e.Notify(ctx, testArticle())
+	sent := waitForMail(t, server, 1)
+	time.Sleep(20 * time.Millisecond)
+	if len(server.sent()) != 1 || sent[0].to[0] != "all@example.org" {
+		t.Errorf("sent to %v", server.sent())
+	}
+}
+
+func TestEmailDigestSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscribers.json")
+	e, _ := newTestEmail(t, path)
+	if _, err := e.Subscribe(models.EmailSubscriber{Address: "daily@example.org", Delivery: models.DeliveryDaily, DigestAt: "08:00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := testArticle(), testArticle()
+	second.Title = "Ethereum upgrade ships"
+	second.Content = "The full text of the article."
+	e.Notify(context.Background(), first)
+	e.Notify(context.Background(), second)
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, server := newTestEmail(t, path)
+	restarted.sendDueDigests(time.Now().Add(25 * time.Hour))
+
+	sent := waitForMail(t, server, 1)
+	_, text, _ := readMail(t, sent[0].msg)
+	if !strings.Contains(text, "2 articles matched") || !strings.Contains(text, first.Title) || !strings.Contains(text, second.Title) {
+		t.Errorf("digest = %q", text)
+	}
+
+	restarted.sendDueDigests(time.Now().Add(26 * time.Hour))
+	if len(server.sent()) != 1 {
+		t.Error("digest was sent twice")
+	}
+}
+
+func TestPendingDigestOmitsPageText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscribers.json")
+	e, _ := newTestEmail(t, path)
+	if _, err := e.Subscribe(models.EmailSubscriber{Address: "daily@example.org", Delivery: models.DeliveryDaily}); err != nil {
+		t.Fatal(err)
+	}
+
+	article := testArticle()
+	article.Content = "The article body."
+	article.FullText = "The full text of the page."
+	article.Metadata = map[string]string{"source_name": "Reuters", "full_text_error": "timeout", "original_title": "Titre"}
+	e.Notify(context.Background(), article)
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{article.Content, article.FullText, "full_text_error", "original_title"} {
+		if strings.Contains(string(data), text) {
+			t.Errorf("subscribers file contains %q", text)
+		}
+	}
+
+	restarted, _ := newTestEmail(t, path)
+	pending := restarted.subscribers[0].pending
+	if len(pending) != 1 || pending[0].Title != article.Title || articleSource(pending[0]) != "Reuters" {
+		t.Errorf("reloaded pending digest = %+v", pending)
+	}
+}
+
+func TestEmailSubscribe(t *testing.T) {
+	tests := []struct {
+		sub     models.EmailSubscriber
+		wantErr string
+	}{
+		{models.EmailSubscriber{Address: "a@example.org"}, ""},
+		{models.EmailSubscriber{Address: "not an address"}, "invalid email address"},
+		{models.EmailSubscriber{Address: "a@example.org", Delivery: "hourly"}, "delivery must be"},
+		{models.EmailSubscriber{Address: "a@example.org", Delivery: models.DeliveryDaily, DigestAt: "8am"}, "at must be"},
+		{models.EmailSubscriber{Address: "a@example.org", Rule: "(bitcoin"}, "invalid rule"},
+	}
+
+	e, _ := newTestEmail(t, "")
+	for _, tt := range tests {
+		_, err := e.Subscribe(tt.sub)
+		if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Subscribe(%+v) = %v, want %q", tt.sub, err, tt.wantErr)
+		}
+	}
+
+	first, _ := e.Subscribe(models.EmailSubscriber{Address: "b@example.org"})
+	again, _ := e.Subscribe(models.EmailSubscriber{Address: "B@example.org", Delivery: models.DeliveryDaily})
+	if again.Token != first.Token || again.DigestAt != defaultDigestAt || len(e.Subscribers()) != 2 {
+		t.Errorf("resubscribing did not update in place: %+v", e.Subscribers())
+	}
+}
+
+func TestHandleUnsubscribe(t *testing.T) {
+	e, _ := newTestEmail(t, "")
+	sub, _ := e.Subscribe(models.EmailSubscriber{Address: "a@example.org"})
+
+	get := httptest.NewRecorder()
+	e.HandleUnsubscribe(get, httptest.NewRequest(http.MethodGet, "/unsubscribe?token="+sub.Token, nil))
+	if len(e.Subscribers()) != 1 {
+		t.Fatal("GET removed the subscriber")
+	}
+
+	post := httptest.NewRecorder()
+	e.HandleUnsubscribe(post, httptest.NewRequest(http.MethodPost, "/unsubscribe?token="+sub.Token, nil))
+	if len(e.Subscribers()) != 0 {
+		t.Error("POST did not remove the subscriber")
+	}
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+)
+
+const maxRetryAfter = 30 * time.Second
+
+// Notifier delivers categorized articles to one channel. Each notifier
+// applies its own subscription rules and formatting, so it may ignore
+// articles that don't match.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, article models.CategorizedArticle) error
+}
+
+// Filter selects which articles a channel receives. Empty fields are not
+// applied, so a zero Filter passes everything.
+type Filter struct {
+	Rule          string   `json:"rule,omitempty"`
+	Categories    []string `json:"categories,omitempty"`
+	MinConfidence float64  `json:"min_confidence,omitempty"`
+
+	expr rules.Expr
+}
+
+func (f *Filter) compile() error {
+	if f.Rule == "" {
+		return nil
+	}
+
+	expr, err := rules.Parse(f.Rule)
+	if err != nil {
+		return fmt.Errorf("invalid rule %q: %w", f.Rule, err)
+	}
+	f.expr = expr
+	return nil
+}
+
+func (f *Filter) Matches(article models.CategorizedArticle) bool {
+	if article.Confidence < f.MinConfidence {
+		return false
+	}
+	if len(f.Categories) > 0 {
+		matched := false
+		for _, category := range f.Categories {
+			if strings.EqualFold(rules.CanonicalCategory(category), article.Category) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return f.expr == nil || f.expr.Eval(article)
+}
+
+// ChannelConfig is one entry of the notifiers file.
+type ChannelConfig struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+	Filter
+}
+
+// Load reads the JSON list of channels at path. An empty path configures
+// no channels.
+func Load(path string) ([]Notifier, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []ChannelConfig
+	if err := json.Unmarshal(data, &channels); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	notifiers := make([]Notifier, 0, len(channels))
+	for i, channel := range channels {
+		if channel.Name == "" {
+			channel.Name = channel.Type
+		}
+		notifier, err := newNotifier(channel)
+		if err != nil {
+			return nil, fmt.Errorf("channel %d (%s): %w", i+1, channel.Name, err)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
+
+func newNotifier(channel ChannelConfig) (Notifier, error) {
+	if !validURL(channel.URL) {
+		return nil, fmt.Errorf("url must be an http or https URL")
+	}
+	if err := channel.Filter.compile(); err != nil {
+		return nil, err
+	}
+
+	switch channel.Type {
+	case "discord":
+		return NewDiscord(channel.Name, channel.URL, channel.Filter), nil
+	case "slack":
+		return NewSlack(channel.Name, channel.URL, channel.Filter), nil
+	case "webhook":
+		if channel.Secret == "" {
+			return nil, fmt.Errorf("webhook channels need a secret to sign requests")
+		}
+		return NewWebhook(channel.Name, channel.URL, channel.Secret, channel.Filter), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q: use discord, slack or webhook", channel.Type)
+	}
+}
+
+func validURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+	}
+}
+
+// postJSON sends body to url, retrying once if the endpoint rate limits
+// the request or fails with a server error.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		if resp.StatusCode < 300 {
+			return nil
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt > 0 {
+			return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+		}
+
+		select {
+		case <-time.After(retryAfter(resp.Header.Get("Retry-After"))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return time.Second
+	}
+	if wait := time.Duration(seconds * float64(time.Second)); wait < maxRetryAfter {
+		return wait
+	}
+	return maxRetryAfter
+}
+
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
+
+func articleSource(article models.CategorizedArticle) string {
+	if name := article.Metadata["source_name"]; name != "" {
+		return name
+	}
+	return article.Source
+}
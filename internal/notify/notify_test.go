@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func testArticle() models.CategorizedArticle {
+	var article models.CategorizedArticle
+	article.Title = "Bitcoin ETF inflows hit a record"
+	article.Summary = "Spot funds took in <$1 billion> & more on Tuesday."
+	article.URL = "https://example.com/etf"
+	article.Source = "newsapi"
+	article.Metadata = map[string]string{"source_name": "Reuters"}
+	article.Category = "cryptocurrency"
+	article.Tags = []string{"bitcoin", "etf"}
+	article.Sentiment = "positive"
+	article.Confidence = 0.8
+	article.PublishedAt = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	return article
+}
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"category", Filter{Categories: []string{"finance", "Cryptocurrency"}}, true},
+		{"other category", Filter{Categories: []string{"sports"}}, false},
+		{"confidence", Filter{MinConfidence: 0.9}, false},
+		{"rule", Filter{Rule: "bitcoin AND sentiment:positive"}, true},
+		{"failing rule", Filter{Rule: "NOT bitcoin"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.filter.compile(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := tt.filter.Matches(testArticle()); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"valid", `[{"type": "discord", "url": "https://discord.test/hook"}, {"type": "webhook", "name": "ops", "url": "http://ops.test", "secret": "s"}]`, ""},
+		{"bad url", `[{"type": "slack", "url": "ftp://slack.test"}]`, "http or https"},
+		{"no secret", `[{"type": "webhook", "url": "https://ops.test"}]`, "need a secret"},
+		{"bad rule", `[{"type": "slack", "url": "https://slack.test", "rule": "(bitcoin"}]`, "invalid rule"},
+		{"unknown type", `[{"type": "teams", "url": "https://teams.test"}]`, "unknown type"},
+		{"bad json", `{`, "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "notifiers.json")
+		os.WriteFile(path, []byte(tt.config), 0o644)
+
+		notifiers, err := Load(path)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case len(notifiers) != 2 || notifiers[0].Name() != "discord" || notifiers[1].Name() != "ops":
+			t.Errorf("%s: loaded %v", tt.name, notifiers)
+		}
+	}
+
+	if notifiers, err := Load(""); notifiers != nil || err != nil {
+		t.Errorf("Load(\"\") = %v, %v", notifiers, err)
+	}
+}
+
+func TestPostJSONRetriesOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		calls    int32
+	}{
+		{"ok", []int{200}, false, 1},
+		{"rate limited then ok", []int{429, 204}, false, 2},
+		{"server errors", []int{503, 502}, true, 2},
+		{"client error", []int{400}, true, 1},
+	}
+
+	for _, tt := range tests {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := calls.Add(1)
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(tt.statuses[n-1])
+		}))
+
+		err := postJSON(context.Background(), server.Client(), server.URL, []byte(`{}`), nil)
+		server.Close()
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if calls.Load() != tt.calls {
+			t.Errorf("%s: %d calls, want %d", tt.name, calls.Load(), tt.calls)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := map[string]time.Duration{"": time.Second, "abc": time.Second, "-1": time.Second, "2": 2 * time.Second, "0.5": 500 * time.Millisecond, "3600": maxRetryAfter}
+	for value, want := range tests {
+		if got := retryAfter(value); got != want {
+			t.Errorf("retryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("héllo wörld", 5); got != "héll…" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncate("short", 5); got != "short" {
+		t.Errorf("got %q", got)
+	}
+}
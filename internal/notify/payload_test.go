@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDiscordPayload(t *testing.T) {
+	article := testArticle()
+	article.Title = strings.Repeat("t", 300)
+	article.URL = "javascript:alert(1)"
+
+	payload := discordPayload(article)
+	embed := payload.Embeds[0]
+
+	if utf8.RuneCountInString(embed.Title) != discordTitleLimit {
+		t.Errorf("title has %d runes, want %d", utf8.RuneCountInString(embed.Title), discordTitleLimit)
+	}
+	if embed.URL != "" {
+		t.Errorf("unsafe URL kept: %q", embed.URL)
+	}
+	if embed.Color != sentimentColors["positive"] || embed.Footer.Text != "Reuters" || embed.Timestamp != "2026-03-10T12:00:00Z" {
+		t.Errorf("embed = %+v", embed)
+	}
+
+	var names []string
+	for _, field := range embed.Fields {
+		names = append(names, field.Name+"="+field.Value)
+	}
+	if got := strings.Join(names, "; "); got != "Category=cryptocurrency; Sentiment=positive; Confidence=80%; Tags=bitcoin, etf" {
+		t.Errorf("fields = %s", got)
+	}
+
+	data, _ := json.Marshal(payload)
+	if !strings.Contains(string(data), `"allowed_mentions":{"parse":[]}`) {
+		t.Errorf("mentions are not disabled: %s", data)
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	payload := slackPayload(testArticle())
+
+	var types []string
+	for _, block := range payload.Blocks {
+		types = append(types, block.Type)
+	}
+	if got := strings.Join(types, ","); got != "header,section,context,actions" {
+		t.Fatalf("blocks = %s", got)
+	}
+
+	if summary := payload.Blocks[1].Text.Text; summary != "Spot funds took in &lt;$1 billion&gt; &amp; more on Tuesday." {
+		t.Errorf("summary not escaped: %q", summary)
+	}
+	context := payload.Blocks[2].Elements[0].(slackText).Text
+	if context != "*cryptocurrency* · positive · 80% confidence · Reuters · bitcoin, etf" {
+		t.Errorf("context = %q", context)
+	}
+	if button := payload.Blocks[3].Elements[0].(slackButton); button.URL != "https://example.com/etf" {
+		t.Errorf("button = %+v", button)
+	}
+
+	article := testArticle()
+	article.Summary = ""
+	article.URL = ""
+	if blocks := slackPayload(article).Blocks; len(blocks) != 2 {
+		t.Errorf("got %d blocks for an article without summary or link, want 2", len(blocks))
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"log"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// QueueSize is how many articles a channel may fall behind before new ones
+// are dropped for it.
+const QueueSize = 1024
+
+// Queue feeds one notifier from its own long-lived goroutine, so a slow or
+// failing channel only delays itself: processing never waits on delivery.
+type Queue struct {
+	notifier Notifier
+	articles chan models.CategorizedArticle
+}
+
+func NewQueue(notifier Notifier, size int) *Queue {
+	return &Queue{
+		notifier: notifier,
+		articles: make(chan models.CategorizedArticle, size),
+	}
+}
+
+func (q *Queue) Name() string {
+	return q.notifier.Name()
+}
+
+// Enqueue adds an article without blocking. It reports false, dropping the
+// article, when the channel's queue is full.
+func (q *Queue) Enqueue(article models.CategorizedArticle) bool {
+	select {
+	case q.articles <- article:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run delivers queued articles in order until ctx is done.
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case article := <-q.articles:
+			if err := q.notifier.Notify(ctx, article); err != nil {
+				log.Printf("Failed to notify %s about %q: %v", q.notifier.Name(), article.Title, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// slowNotifier records titles, blocking each delivery until release closes.
+type slowNotifier struct {
+	release chan struct{}
+	mu      sync.Mutex
+	titles  []string
+}
+
+func (n *slowNotifier) Name() string { return "slow" }
+
+func (n *slowNotifier) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	<-n.release
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.titles = append(n.titles, article.Title)
+	return nil
+}
+
+func (n *slowNotifier) delivered() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.titles...)
+}
+
+func TestQueueDeliversInOrderWithoutBlocking(t *testing.T) {
+	notifier := &slowNotifier{release: make(chan struct{})}
+	queue := NewQueue(notifier, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx)
+
+	var article models.CategorizedArticle
+	for _, title := range []string{"a", "b", "c"} {
+		article.Title = title
+		if !queue.Enqueue(article) {
+			t.Fatalf("Enqueue(%s) was refused", title)
+		}
+		// Let Run take the first article so the rest sit in the buffer.
+		time.Sleep(10 * time.Millisecond)
+	}
+	article.Title = "d"
+	if queue.Enqueue(article) {
+		t.Error("Enqueue accepted an article past the queue size")
+	}
+
+	close(notifier.release)
+	deadline := time.Now().Add(time.Second)
+	for len(notifier.delivered()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := notifier.delivered(); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("delivered %v, want [a b c]", got)
+	}
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// Slack Block Kit limits.
+const (
+	slackHeaderLimit  = 150
+	slackSectionLimit = 3000
+)
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Slack posts articles to a Slack incoming webhook using Block Kit.
+type Slack struct {
+	name   string
+	url    string
+	filter Filter
+	client *http.Client
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string        `json:"type"`
+	Text     *slackText    `json:"text,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackButton struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+func NewSlack(name, url string, filter Filter) *Slack {
+	return &Slack{
+		name:   name,
+		url:    url,
+		filter: filter,
+		client: newHTTPClient(),
+	}
+}
+
+func (s *Slack) Name() string {
+	return s.name
+}
+
+func (s *Slack) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	if !s.filter.Matches(article) {
+		return nil
+	}
+
+	body, err := json.Marshal(slackPayload(article))
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.client, s.url, body, nil)
+}
+
+func slackPayload(article models.CategorizedArticle) slackMessage {
+	blocks := []slackBlock{{
+		Type: "header",
+		Text: &slackText{Type: "plain_text", Text: truncate(article.Title, slackHeaderLimit)},
+	}}
+
+	if article.Summary != "" {
+		blocks = append(blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: truncate(slackEscaper.Replace(article.Summary), slackSectionLimit)},
+		})
+	}
+
+	var details []string
+	if article.Category != "" {
+		details = append(details, "*"+slackEscaper.Replace(article.Category)+"*")
+	}
+	if article.Sentiment != "" {
+		details = append(details, slackEscaper.Replace(article.Sentiment))
+	}
+	details = append(details, fmt.Sprintf("%.0f%% confidence", article.Confidence*100))
+	if source := articleSource(article); source != "" {
+		details = append(details, slackEscaper.Replace(source))
+	}
+	if len(article.Tags) > 0 {
+		details = append(details, slackEscaper.Replace(strings.Join(article.Tags, ", ")))
+	}
+	blocks = append(blocks, slackBlock{
+		Type:     "context",
+		Elements: []interface{}{slackText{Type: "mrkdwn", Text: truncate(strings.Join(details, " · "), slackSectionLimit)}},
+	})
+
+	if validURL(article.URL) {
+		blocks = append(blocks, slackBlock{
+			Type: "actions",
+			Elements: []interface{}{slackButton{
+				Type: "button",
+				Text: slackText{Type: "plain_text", Text: "Read more"},
+				URL:  article.URL,
+			}},
+		})
+	}
+
+	return slackMessage{
+		Text:   truncate(article.Title, slackSectionLimit),
+		Blocks: blocks,
+	}
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// Webhook POSTs articles as JSON to any endpoint, signed with HMAC-SHA256
+// so receivers can verify the request came from us.
+type Webhook struct {
+	name   string
+	url    string
+	secret []byte
+	filter Filter
+	client *http.Client
+}
+
+type webhookEvent struct {
+	Event   string                    `json:"event"`
+	SentAt  time.Time                 `json:"sent_at"`
+	Article models.CategorizedArticle `json:"article"`
+}
+
+func NewWebhook(name, url, secret string, filter Filter) *Webhook {
+	return &Webhook{
+		name:   name,
+		url:    url,
+		secret: []byte(secret),
+		filter: filter,
+		client: newHTTPClient(),
+	}
+}
+
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+func (w *Webhook) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	if !w.filter.Matches(article) {
+		return nil
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(webhookEvent{Event: "article", SentAt: now.UTC(), Article: article})
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	header := http.Header{}
+	header.Set("X-HFNews-Timestamp", timestamp)
+	header.Set("X-HFNews-Signature", "sha256="+Sign(w.secret, timestamp, body))
+	return postJSON(ctx, w.client, w.url, body, header)
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body". Including the
+// timestamp lets receivers reject replayed requests.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
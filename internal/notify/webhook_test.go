@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign([]byte("secret"), "1700000000", []byte(`{"event":"article"}`))
+	if want := "dd0f6db720e646d2262adb981917331047304873435121b174e9a0ebed2bc248"; got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign([]byte("other"), "1700000000", []byte(`{"event":"article"}`)) == got {
+		t.Error("signature does not depend on the secret")
+	}
+	if Sign([]byte("secret"), "1700000001", []byte(`{"event":"article"}`)) == got {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestWebhookNotifySignsBody(t *testing.T) {
+	var verified bool
+	var event webhookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-HFNews-Timestamp")
+		verified = r.Header.Get("X-HFNews-Signature") == "sha256="+Sign([]byte("s3cret"), timestamp, body)
+
+		if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+			t.Errorf("bad timestamp %q", timestamp)
+		}
+		json.Unmarshal(body, &event)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook("ops", server.URL, "s3cret", Filter{})
+	if err := webhook.Notify(context.Background(), testArticle()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !verified {
+		t.Error("signature did not verify")
+	}
+	if event.Event != "article" || event.Article.Title != testArticle().Title {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestWebhookSkipsFilteredArticles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("filtered article was posted")
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook("ops", server.URL, "s3cret", Filter{Categories: []string{"sports"}})
+	if err := webhook.Notify(context.Background(), testArticle()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,253 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/fileutil"
+)
+
+const (
+	TierFree  = "free"
+	TierPro   = "pro"
+	TierAdmin = "admin"
+)
+
+// Limits caps what a tier may do. A zero AlertsPerDay means unlimited.
+type Limits struct {
+	Alerts       int
+	AlertsPerDay int
+	AI           bool
+}
+
+var tierLimits = map[string]Limits{
+	TierFree:  {Alerts: 3, AlertsPerDay: 25},
+	TierPro:   {Alerts: 20, AlertsPerDay: 500, AI: true},
+	TierAdmin: {Alerts: 100, AI: true},
+}
+
+func LimitsFor(tier string) Limits {
+	if limits, ok := tierLimits[tier]; ok {
+		return limits
+	}
+	return tierLimits[TierFree]
+}
+
+func ValidTier(tier string) bool {
+	_, ok := tierLimits[tier]
+	return ok
+}
+
+// usage counts the alerts sent to an ID on one UTC day.
+type usage struct {
+	Day      string `json:"day"`
+	Alerts   int    `json:"alerts"`
+	Notified bool   `json:"notified,omitempty"`
+}
+
+type state struct {
+	Tiers map[int64]string `json:"tiers"`
+	Usage map[int64]*usage `json:"usage"`
+}
+
+// FlushInterval is how often Run writes changed counters to disk.
+const FlushInterval = 30 * time.Second
+
+// Store keeps tiers and daily counters for Telegram user and chat IDs. Tier
+// changes are saved at once; counters only mark the store dirty and are
+// written by Flush, so sending alerts never waits on the disk.
+type Store struct {
+	path  string
+	state state
+	// reserved counts alerts queued for sending but not yet delivered. It
+	// only lives in memory: a restart drops the queue along with it.
+	reserved map[int64]int
+	dirty    bool
+	mu       sync.Mutex
+}
+
+// NewStore returns a store that only lives in memory.
+func NewStore() *Store {
+	return &Store{
+		state: state{
+			Tiers: make(map[int64]string),
+			Usage: make(map[int64]*usage),
+		},
+		reserved: make(map[int64]int),
+	}
+}
+
+// Open loads the store saved at path, starting empty if the file does not
+// exist yet.
+func Open(path string) (*Store, error) {
+	s := NewStore()
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if s.state.Tiers == nil {
+		s.state.Tiers = make(map[int64]string)
+	}
+	if s.state.Usage == nil {
+		s.state.Usage = make(map[int64]*usage)
+	}
+	return s, nil
+}
+
+func (s *Store) Tier(id int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tier, ok := s.state.Tiers[id]; ok {
+		return tier
+	}
+	return TierFree
+}
+
+func (s *Store) SetTier(id int64, tier string) error {
+	if !ValidTier(tier) {
+		return fmt.Errorf("unknown tier %q", tier)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tier == TierFree {
+		delete(s.state.Tiers, id)
+	} else {
+		s.state.Tiers[id] = tier
+	}
+	return s.saveLocked()
+}
+
+// Reserve sets aside up to n of today's alerts for messages about to be
+// queued, counting earlier reservations that have not been delivered yet.
+// It returns how many fit under limit; when some do not, notify is true only
+// the first time that day so the user hears about it once. Every granted
+// alert must later be passed to Commit or Release.
+func (s *Store) Reserve(id int64, n, limit int, now time.Time) (granted int, notify bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	granted = n
+	u := s.usageLocked(id, now)
+	if limit > 0 {
+		granted = max(0, min(n, limit-u.Alerts-s.reserved[id]))
+	}
+	if granted < n {
+		notify = !u.Notified
+		u.Notified = true
+		s.dirty = s.dirty || notify
+	}
+
+	s.reserved[id] += granted
+	return granted, notify
+}
+
+// Commit counts n reserved alerts as delivered today.
+func (s *Store) Commit(id int64, n int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.releaseLocked(id, n)
+	s.usageLocked(id, now).Alerts += n
+	s.dirty = true
+}
+
+// Release returns n reserved alerts that were never delivered.
+func (s *Store) Release(id int64, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.releaseLocked(id, n)
+}
+
+func (s *Store) releaseLocked(id int64, n int) {
+	if s.reserved[id] -= n; s.reserved[id] <= 0 {
+		delete(s.reserved, id)
+	}
+}
+
+// AlertsToday reports how many alerts the ID has been sent today.
+func (s *Store) AlertsToday(id int64, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.state.Usage[id]; ok && u.Day == day(now) {
+		return u.Alerts
+	}
+	return 0
+}
+
+func (s *Store) usageLocked(id int64, now time.Time) *usage {
+	u, ok := s.state.Usage[id]
+	if !ok || u.Day != day(now) {
+		u = &usage{Day: day(now)}
+		s.state.Usage[id] = u
+	}
+	return u
+}
+
+// Flush writes the counters if they changed since the last save.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// Run flushes the store every FlushInterval until ctx is done. Call Flush
+// once more on shutdown to keep the last counters.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Printf("Failed to save quotas to %s: %v", s.path, err)
+			}
+		}
+	}
+}
+
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteAtomic(s.path, data); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func day(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
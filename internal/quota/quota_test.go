@@ -0,0 +1,121 @@
+package quota
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLimitsFor(t *testing.T) {
+	tests := map[string]Limits{
+		TierFree:  {Alerts: 3, AlertsPerDay: 25},
+		TierPro:   {Alerts: 20, AlertsPerDay: 500, AI: true},
+		TierAdmin: {Alerts: 100, AI: true},
+		"gold":    {Alerts: 3, AlertsPerDay: 25},
+	}
+	for tier, want := range tests {
+		if got := LimitsFor(tier); got != want {
+			t.Errorf("LimitsFor(%q) = %+v, want %+v", tier, got, want)
+		}
+	}
+}
+
+func TestReserve(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		delivered   int
+		reserved    int
+		n, limit    int
+		wantGranted int
+	}{
+		{"room left", 0, 0, 1, 25, 1},
+		{"partly", 20, 3, 5, 25, 2},
+		{"full", 24, 1, 1, 25, 0},
+		{"unlimited", 1000, 0, 4, 0, 4},
+	}
+
+	for _, tt := range tests {
+		s := NewStore()
+		s.Commit(1, tt.delivered, now)
+		s.Reserve(1, tt.reserved, tt.limit, now)
+
+		granted, notify := s.Reserve(1, tt.n, tt.limit, now)
+		if granted != tt.wantGranted {
+			t.Errorf("%s: granted %d, want %d", tt.name, granted, tt.wantGranted)
+		}
+		if notify != (granted < tt.n) {
+			t.Errorf("%s: notify = %v", tt.name, notify)
+		}
+	}
+}
+
+func TestReserveNotifiesOncePerDay(t *testing.T) {
+	s := NewStore()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	s.Commit(1, 25, now)
+
+	if _, notify := s.Reserve(1, 1, 25, now); !notify {
+		t.Error("first refusal did not notify")
+	}
+	if _, notify := s.Reserve(1, 1, 25, now); notify {
+		t.Error("second refusal notified again")
+	}
+	if granted, _ := s.Reserve(1, 1, 25, now.Add(24*time.Hour)); granted != 1 {
+		t.Error("counter did not reset the next day")
+	}
+}
+
+func TestCommitAndRelease(t *testing.T) {
+	s := NewStore()
+	now := time.Now()
+
+	s.Reserve(1, 5, 10, now)
+	s.Commit(1, 2, now)
+	s.Release(1, 3)
+
+	if got := s.AlertsToday(1, now); got != 2 {
+		t.Errorf("AlertsToday = %d, want 2", got)
+	}
+	if granted, _ := s.Reserve(1, 10, 10, now); granted != 8 {
+		t.Errorf("granted %d, want 8", granted)
+	}
+}
+
+func TestFlushOnlyWritesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	s.Reserve(7, 1, 25, now)
+	s.Commit(7, 1, now)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("counters were written before Flush: %v", err)
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Flush rewrote an unchanged store")
+	}
+
+	s.Commit(7, 1, now)
+	s.SetTier(7, TierPro)
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Tier(7) != TierPro || reopened.AlertsToday(7, now) != 2 {
+		t.Errorf("reopened store has tier %s and %d alerts", reopened.Tier(7), reopened.AlertsToday(7, now))
+	}
+}
@@ -0,0 +1,216 @@
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type Mode string
+
+const (
+	ModeOff    Mode = ""
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+// Bodies are stored as BodyJSON when they are valid JSON, so fixtures stay
+// readable, and as BodyText otherwise.
+type FixtureRequest struct {
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	BodyJSON json.RawMessage `json:"body_json,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+type FixtureResponse struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	BodyJSON   json.RawMessage   `json:"body_json,omitempty"`
+	BodyText   string            `json:"body_text,omitempty"`
+}
+
+// Transport records HTTP exchanges to fixture files or serves them back,
+// keyed on the request method, path and body so that identical prompts
+// always resolve to the same fixture.
+type Transport struct {
+	mode Mode
+	dir  string
+	next http.RoundTripper
+	mu   sync.Mutex
+}
+
+func ParseMode(value string) (Mode, error) {
+	switch Mode(value) {
+	case ModeOff, ModeRecord, ModeReplay:
+		return Mode(value), nil
+	default:
+		return ModeOff, fmt.Errorf("unknown record mode %q", value)
+	}
+}
+
+func NewTransport(mode Mode, dir string, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{
+		mode: mode,
+		dir:  dir,
+		next: next,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == ModeOff {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fixtureKey(req, body)
+
+	if t.mode == ModeReplay {
+		fixture, err := t.load(key)
+		if err != nil {
+			return nil, fmt.Errorf("no recorded fixture for %s %s (%s): %w", req.Method, req.URL.Path, key, err)
+		}
+		return fixture.Response.toHTTP(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := Fixture{
+		Request: FixtureRequest{
+			Method: req.Method,
+			URL:    req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		},
+		Response: FixtureResponse{
+			StatusCode: resp.StatusCode,
+			Header: map[string]string{
+				"Content-Type": resp.Header.Get("Content-Type"),
+			},
+		},
+	}
+	fixture.Request.BodyJSON, fixture.Request.BodyText = splitBody(body)
+	fixture.Response.BodyJSON, fixture.Response.BodyText = splitBody(respBody)
+
+	if err := t.save(key, fixture); err != nil {
+		return nil, fmt.Errorf("failed to record fixture: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (t *Transport) load(key string) (Fixture, error) {
+	var fixture Fixture
+
+	data, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return fixture, err
+	}
+
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return fixture, err
+	}
+
+	return fixture, nil
+}
+
+func (t *Transport) save(key string, fixture Fixture) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(t.dir, key+".json"), data, 0o644)
+}
+
+func (r FixtureResponse) toHTTP(req *http.Request) *http.Response {
+	header := make(http.Header, len(r.Header))
+	for key, value := range r.Header {
+		header.Set(key, value)
+	}
+
+	body := []byte(r.BodyJSON)
+	if len(body) == 0 {
+		body = []byte(r.BodyText)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func fixtureKey(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte(req.URL.RawQuery))
+	h.Write(body)
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+func splitBody(data []byte) (json.RawMessage, string) {
+	if len(data) == 0 {
+		return nil, ""
+	}
+	if json.Valid(data) {
+		return json.RawMessage(data), ""
+	}
+	return nil, string(data)
+}
@@ -0,0 +1,117 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type stubTransport struct {
+	body  string
+	calls int
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Mode
+		wantErr bool
+	}{
+		{"", ModeOff, false},
+		{"record", ModeRecord, false},
+		{"replay", ModeReplay, false},
+		{"Replay", ModeOff, true},
+		{"playback", ModeOff, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMode(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"json object", `{"answer": 42}`},
+		{"json string", `"a JSON string, not text"`},
+		{"plain text", "rate limited, try again"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			upstream := &stubTransport{body: tt.body}
+
+			recorded := roundTrip(t, NewTransport(ModeRecord, dir, upstream), "prompt")
+			if recorded != tt.body {
+				t.Fatalf("record mode returned %q, want %q", recorded, tt.body)
+			}
+
+			replayed := roundTrip(t, NewTransport(ModeReplay, dir, upstream), "prompt")
+			if upstream.calls != 1 {
+				t.Errorf("upstream called %d times, want 1", upstream.calls)
+			}
+			if compact(replayed) != compact(tt.body) {
+				t.Errorf("replayed body %q, want %q", replayed, tt.body)
+			}
+		})
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	dir := t.TempDir()
+	roundTrip(t, NewTransport(ModeRecord, dir, &stubTransport{body: "{}"}), "first prompt")
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/v1/chat", strings.NewReader("second prompt"))
+	if _, err := NewTransport(ModeReplay, dir, nil).RoundTrip(req); err == nil {
+		t.Fatal("expected an error for a request with no fixture")
+	}
+}
+
+func roundTrip(t *testing.T, transport *Transport, body string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/chat", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// compact ignores the indentation fixture files add to JSON bodies.
+func compact(s string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return s
+	}
+	return buf.String()
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+type token struct {
+	kind   tokenKind
+	field  string
+	value  string
+	quoted bool
+	pos    int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of rule"
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	}
+
+	value := t.value
+	if t.quoted {
+		value = fmt.Sprintf("%q", value)
+	}
+	if t.field != "" {
+		return fmt.Sprintf("'%s:%s'", t.field, value)
+	}
+	return fmt.Sprintf("'%s'", value)
+}
+
+// ParseError reports a problem at a rune offset within the rule text.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: i})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, token{kind: tokenNot, pos: i})
+			i++
+		case r == '"':
+			value, next, err := lexQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenTerm, value: value, quoted: true, pos: i})
+			i = next
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' && runes[i] != ':' {
+				i++
+			}
+			word := string(runes[start:i])
+
+			if i < len(runes) && runes[i] == ':' {
+				if word == "" {
+					return nil, &ParseError{Pos: start, Msg: "missing field name before ':'"}
+				}
+				i++
+				tok := token{kind: tokenTerm, field: strings.ToLower(word), pos: start}
+
+				switch {
+				case i < len(runes) && runes[i] == '"':
+					value, next, err := lexQuoted(runes, i)
+					if err != nil {
+						return nil, err
+					}
+					tok.value, tok.quoted, i = value, true, next
+				default:
+					valueStart := i
+					for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+						i++
+					}
+					tok.value = string(runes[valueStart:i])
+				}
+
+				if tok.value == "" {
+					return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("missing value after '%s:'", word)}
+				}
+				tokens = append(tokens, tok)
+				continue
+			}
+
+			switch strings.ToUpper(word) {
+			case "AND", "&&":
+				tokens = append(tokens, token{kind: tokenAnd, pos: start})
+			case "OR", "||":
+				tokens = append(tokens, token{kind: tokenOr, pos: start})
+			case "NOT", "!":
+				tokens = append(tokens, token{kind: tokenNot, pos: start})
+			default:
+				tokens = append(tokens, token{kind: tokenTerm, value: word, pos: start})
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func lexQuoted(runes []rune, start int) (string, int, error) {
+	var sb strings.Builder
+	i := start + 1
+
+	for i < len(runes) {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				sb.WriteRune(runes[i+1])
+				i += 2
+				continue
+			}
+		case '"':
+			if sb.Len() == 0 {
+				return "", 0, &ParseError{Pos: start, Msg: "empty quoted phrase"}
+			}
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteRune(runes[i])
+		i++
+	}
+
+	return "", 0, &ParseError{Pos: start, Msg: "unterminated quote"}
+}
@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grammar, lowest precedence first:
+//
+//	expr   = and { OR and }
+//	and    = unary { [AND] unary }
+//	unary  = NOT unary | '-' unary | primary
+//	primary = '(' expr ')' | term
+//	term   = [field ':'] (word | "quoted phrase")
+//
+// Adjacent terms without an operator are joined with AND.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func Parse(input string) (Expr, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, &ParseError{Pos: 0, Msg: "rule is empty"}
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		if tok.kind == tokenRParen {
+			return nil, &ParseError{Pos: tok.pos, Msg: "unexpected ')' without matching '('"}
+		}
+		return nil, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenNot, tokenTerm, tokenLParen:
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &ParseError{Pos: tok.pos, Msg: "missing ')' to close '('"}
+		}
+		return expr, nil
+	case tokenTerm:
+		return newTerm(tok)
+	case tokenEOF:
+		return nil, &ParseError{Pos: tok.pos, Msg: "rule ends unexpectedly, expected a term"}
+	default:
+		return nil, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("expected a term but found %s", tok)}
+	}
+}
@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"bitcoin", "bitcoin"},
+		{"bitcoin ethereum", "(bitcoin AND ethereum)"},
+		{"bitcoin AND ethereum OR solana", "((bitcoin AND ethereum) OR solana)"},
+		{"bitcoin OR ethereum solana", "(bitcoin OR (ethereum AND solana))"},
+		{"bitcoin && (ethereum || solana)", "(bitcoin AND (ethereum OR solana))"},
+		{"NOT scam", "NOT scam"},
+		{"-scam bitcoin", "(NOT scam AND bitcoin)"},
+		{"! NOT scam", "NOT NOT scam"},
+		{`"rate cut" category:finance`, `("rate cut" AND category:finance)`},
+		{"cat:crypto", "category:cryptocurrency"},
+		{"keyword:etf tags:defi language:es", "((etf AND tag:defi) AND lang:es)"},
+		{`title:"Fed \"hawkish\""`, `title:"Fed \"hawkish\""`},
+		{"bitcoin-etf", "bitcoin-etf"},
+		{"source:Reuters.com", "source:Reuters.com"},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.rule, err)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		pos  int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"bitcoin AND", 11},
+		{"(bitcoin OR ethereum", 0},
+		{"bitcoin)", 7},
+		{`"unterminated`, 0},
+		{`""`, 0},
+		{"color:red", 0},
+		{"category:", 0},
+		{":value", 0},
+		{"OR bitcoin", 0},
+		{"bitcoin AND AND ethereum", 12},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.rule)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q) = %v, want a ParseError", tt.rule, err)
+			continue
+		}
+		if parseErr.Pos != tt.pos {
+			t.Errorf("Parse(%q) error at %d (%v), want %d", tt.rule, parseErr.Pos, parseErr, tt.pos)
+		}
+	}
+}
+
+func TestLexTokens(t *testing.T) {
+	tokens, err := lex(`tag:defi -"rug pull" (a || b)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []tokenKind{tokenTerm, tokenNot, tokenTerm, tokenLParen, tokenTerm, tokenOr, tokenTerm, tokenRParen, tokenEOF}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %v", len(tokens), len(want), tokens)
+	}
+	for i, kind := range want {
+		if tokens[i].kind != kind {
+			t.Errorf("token %d is %v, want kind %d", i, tokens[i], kind)
+		}
+	}
+	if tokens[0].field != "tag" || tokens[0].value != "defi" {
+		t.Errorf("field term lexed as %+v", tokens[0])
+	}
+	if !tokens[2].quoted || tokens[2].value != "rug pull" || tokens[2].pos != 10 {
+		t.Errorf("quoted term lexed as %+v", tokens[2])
+	}
+}
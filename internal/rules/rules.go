@@ -0,0 +1,210 @@
+package rules
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+type Expr interface {
+	Eval(article models.CategorizedArticle) bool
+	String() string
+}
+
+type andExpr struct{ left, right Expr }
+type orExpr struct{ left, right Expr }
+type notExpr struct{ operand Expr }
+
+func (e andExpr) Eval(a models.CategorizedArticle) bool { return e.left.Eval(a) && e.right.Eval(a) }
+func (e orExpr) Eval(a models.CategorizedArticle) bool  { return e.left.Eval(a) || e.right.Eval(a) }
+func (e notExpr) Eval(a models.CategorizedArticle) bool { return !e.operand.Eval(a) }
+
+func (e andExpr) String() string { return fmt.Sprintf("(%s AND %s)", e.left, e.right) }
+func (e orExpr) String() string  { return fmt.Sprintf("(%s OR %s)", e.left, e.right) }
+func (e notExpr) String() string { return fmt.Sprintf("NOT %s", e.operand) }
+
+type termExpr struct {
+	field string
+	value string
+	match func(article models.CategorizedArticle, value string) bool
+}
+
+func (e termExpr) Eval(a models.CategorizedArticle) bool { return e.match(a, e.value) }
+
+func (e termExpr) String() string {
+	value := e.value
+	if strings.ContainsAny(value, " ()\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+	if e.field == "text" {
+		return value
+	}
+	return e.field + ":" + value
+}
+
+var fields = map[string]func(models.CategorizedArticle, string) bool{
+	"text":      matchText,
+	"title":     func(a models.CategorizedArticle, v string) bool { return containsFold(a.Title, v) },
+	"category":  func(a models.CategorizedArticle, v string) bool { return strings.EqualFold(a.Category, v) },
+	"tag":       matchTag,
+	"source":    matchSource,
+	"sentiment": func(a models.CategorizedArticle, v string) bool { return strings.EqualFold(a.Sentiment, v) },
+	"lang":      func(a models.CategorizedArticle, v string) bool { return strings.EqualFold(a.Language, v) },
+}
+
+var fieldAliases = map[string]string{
+	"keyword":  "text",
+	"cat":      "category",
+	"tags":     "tag",
+	"language": "lang",
+}
+
+// categoryAliases lets rules use the short names people naturally type.
+var categoryAliases = map[string]string{
+	"crypto": "cryptocurrency",
+	"tech":   "technology",
+}
+
+// CanonicalCategory maps a short category name such as "crypto" to the name
+// the categorizer uses; other values are returned unchanged.
+func CanonicalCategory(value string) string {
+	if canonical, ok := categoryAliases[strings.ToLower(value)]; ok {
+		return canonical
+	}
+	return value
+}
+
+func newTerm(tok token) (Expr, error) {
+	field := tok.field
+	if field == "" {
+		field = "text"
+	}
+	if alias, ok := fieldAliases[field]; ok {
+		field = alias
+	}
+
+	match, ok := fields[field]
+	if !ok {
+		return nil, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("unknown field %q (use one of: %s)", tok.field, strings.Join(FieldNames(), ", "))}
+	}
+
+	value := tok.value
+	if field == "category" {
+		value = CanonicalCategory(value)
+	}
+
+	return termExpr{field: field, value: value, match: match}, nil
+}
+
+func FieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func matchText(a models.CategorizedArticle, value string) bool {
+	return containsFold(a.Title, value) || containsFold(a.Content, value) ||
+		containsFold(a.Summary, value) || containsFold(a.Metadata["original_title"], value)
+}
+
+func matchTag(a models.CategorizedArticle, value string) bool {
+	for _, tag := range a.Tags {
+		if strings.EqualFold(tag, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSource(a models.CategorizedArticle, value string) bool {
+	return strings.EqualFold(a.Source, value) || strings.EqualFold(a.Metadata["source_name"], value)
+}
+
+func containsFold(text, value string) bool {
+	return text != "" && strings.Contains(strings.ToLower(text), strings.ToLower(value))
+}
+
+// maxCompiledRules bounds the parse cache; rules come from users through
+// Telegram and the REST API, so the set of distinct strings is unbounded.
+const maxCompiledRules = 1024
+
+var compiled = newRuleCache(maxCompiledRules)
+
+// Match evaluates rule against article, caching the parsed expression so
+// that a rule shared by many articles is only parsed once.
+func Match(rule string, article models.CategorizedArticle) (bool, error) {
+	if expr, ok := compiled.get(rule); ok {
+		return expr.Eval(article), nil
+	}
+
+	expr, err := Parse(rule)
+	if err != nil {
+		return false, err
+	}
+	compiled.add(rule, expr)
+
+	return expr.Eval(article), nil
+}
+
+// ruleCache is a least-recently-used cache of parsed rules.
+type ruleCache struct {
+	mu    sync.Mutex
+	limit int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cachedRule struct {
+	rule string
+	expr Expr
+}
+
+func newRuleCache(limit int) *ruleCache {
+	return &ruleCache{
+		limit: limit,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *ruleCache) get(rule string) (Expr, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[rule]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cachedRule).expr, true
+}
+
+func (c *ruleCache) add(rule string, expr Expr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[rule]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[rule] = c.order.PushFront(&cachedRule{rule: rule, expr: expr})
+	for c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cachedRule).rule)
+	}
+}
+
+func (c *ruleCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func TestMatch(t *testing.T) {
+	article := models.CategorizedArticle{Article: models.Article{
+		Title:     "Bitcoin ETF inflows hit a record",
+		Content:   "Spot funds took in $1 billion.",
+		Summary:   "Largest daily inflow since launch.",
+		Source:    "NewsAPI",
+		Category:  "cryptocurrency",
+		Tags:      []string{"bitcoin", "ETF"},
+		Sentiment: "positive",
+		Language:  "en",
+		Metadata:  map[string]string{"source_name": "Reuters", "original_title": "Entradas récord"},
+	}}
+
+	tests := []struct {
+		rule string
+		want bool
+	}{
+		{"bitcoin", true},
+		{"BITCOIN etf", true},
+		{"bitcoin ethereum", false},
+		{"bitcoin OR ethereum", true},
+		{"-ethereum bitcoin", true},
+		{"NOT bitcoin", false},
+		{`"daily inflow"`, true},
+		{`"inflow daily"`, false},
+		{"category:crypto", true},
+		{"category:finance OR tag:etf", true},
+		{"title:billion", false},
+		{"billion", true},
+		{"source:reuters", true},
+		{"source:newsapi sentiment:positive lang:en", true},
+		{"récord", true},
+		{"(category:finance OR category:crypto) -sentiment:negative", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Match(tt.rule, article)
+		if err != nil {
+			t.Errorf("Match(%q): %v", tt.rule, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestMatchInvalidRule(t *testing.T) {
+	if _, err := Match("bitcoin AND", models.CategorizedArticle{}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestRuleCacheIsBounded(t *testing.T) {
+	cache := newRuleCache(3)
+	for i := 0; i < 5; i++ {
+		rule := fmt.Sprintf("term%d", i)
+		expr, _ := Parse(rule)
+		cache.add(rule, expr)
+		if i == 2 {
+			// Touch the oldest entry so the next eviction skips it.
+			cache.get("term0")
+		}
+	}
+
+	if cache.len() != 3 {
+		t.Fatalf("cache holds %d rules, want 3", cache.len())
+	}
+	for rule, want := range map[string]bool{"term0": true, "term1": false, "term2": false, "term3": true, "term4": true} {
+		if _, ok := cache.get(rule); ok != want {
+			t.Errorf("%s cached = %v, want %v", rule, ok, want)
+		}
+	}
+}
+
+func TestCanonicalCategory(t *testing.T) {
+	for in, want := range map[string]string{"crypto": "cryptocurrency", "Tech": "technology", "finance": "finance"} {
+		if got := CanonicalCategory(in); got != want {
+			t.Errorf("CanonicalCategory(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
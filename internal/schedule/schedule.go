@@ -0,0 +1,19 @@
+package schedule
+
+import "time"
+
+// LastOccurrence returns the most recent occurrence of the HH:MM clock time
+// at or before now, in now's location. Daily digests are due once the
+// previous send is older than this.
+func LastOccurrence(clock string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	scheduled := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if scheduled.After(now) {
+		scheduled = scheduled.AddDate(0, 0, -1)
+	}
+	return scheduled, nil
+}
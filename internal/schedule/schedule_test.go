@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastOccurrence(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	tests := []struct {
+		clock string
+		now   time.Time
+		want  time.Time
+	}{
+		{"08:00", time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC), time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{"08:00", time.Date(2026, 3, 10, 7, 59, 0, 0, time.UTC), time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{"08:00", time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{"23:45", time.Date(2026, 1, 1, 0, 10, 0, 0, time.UTC), time.Date(2025, 12, 31, 23, 45, 0, 0, time.UTC)},
+		{"08:00", time.Date(2026, 3, 10, 9, 0, 0, 0, ny), time.Date(2026, 3, 10, 8, 0, 0, 0, ny)},
+	}
+
+	for _, tt := range tests {
+		got, err := LastOccurrence(tt.clock, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("LastOccurrence(%s, %s) = %s, want %s", tt.clock, tt.now, got, tt.want)
+		}
+	}
+
+	if _, err := LastOccurrence("25:00", time.Now()); err == nil {
+		t.Error("expected an error for an invalid clock time")
+	}
+}
@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// Feed is one client's view of the hub: its filter, the articles it missed
+// while disconnected, and the cursor that stops replayed articles from being
+// sent twice.
+type Feed struct {
+	sub      *Subscription
+	articles *cache.Cache
+	query    cache.Query
+	cursor   Cursor
+	resume   bool
+}
+
+// Open subscribes to the hub and remembers where a resuming client left
+// off. It subscribes before Replay reads the cache so nothing published in
+// between is lost.
+func (h *Hub) Open(query cache.Query, lastEventID string) (*Feed, error) {
+	var cursor Cursor
+	if lastEventID != "" {
+		var err error
+		if cursor, err = ParseEventID(lastEventID); err != nil {
+			return nil, err
+		}
+	}
+
+	sub, err := h.Subscribe()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Feed{sub: sub, articles: h.articles, query: query, cursor: cursor, resume: lastEventID != ""}, nil
+}
+
+// Replay passes every article processed since the last event ID to send,
+// oldest first, reading the cache a page at a time. Call it once before
+// reading Events; it does nothing for a feed opened without an event ID.
+// Live events arriving meanwhile are buffered, and a replay long enough to
+// fill the buffer drops the subscription like any slow client.
+func (f *Feed) Replay(send func(models.CategorizedArticle) error) error {
+	if !f.resume {
+		return nil
+	}
+	f.resume = false
+
+	from := f.cursor
+	for {
+		page := f.articles.CategorizedAfter(from.ProcessedAt, from.Hash, replayPageSize)
+		for _, article := range page {
+			if err := send(article); err != nil {
+				return err
+			}
+		}
+		if len(page) < replayPageSize {
+			return nil
+		}
+		from = CursorOf(page[len(page)-1])
+	}
+}
+
+func (f *Feed) Events() <-chan models.CategorizedArticle {
+	return f.sub.Events()
+}
+
+// Next advances the cursor past article and returns its event ID. ok is
+// false for articles already sent or filtered out.
+func (f *Feed) Next(article models.CategorizedArticle) (id string, ok bool) {
+	if !f.cursor.ProcessedAt.IsZero() && !f.cursor.Before(article) {
+		return "", false
+	}
+	f.cursor = CursorOf(article)
+	return f.cursor.EventID(), f.query.Matches(article)
+}
+
+func (f *Feed) Close() {
+	f.sub.Close()
+}
@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// replayed collects the event IDs a feed would send while replaying.
+func replayed(t *testing.T, feed *Feed) []string {
+	t.Helper()
+	var ids []string
+	err := feed.Replay(func(article models.CategorizedArticle) error {
+		if id, ok := feed.Next(article); ok {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestFeedReplay(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	total := 2*replayPageSize + 7
+
+	articles := cache.New(24 * time.Hour)
+	t.Cleanup(articles.Close)
+	for i := 0; i < total; i++ {
+		a := article(fmt.Sprintf("%06d", i), base.Add(time.Duration(i)*time.Second))
+		if i%2 == 1 {
+			a.Category = "sports"
+		}
+		articles.AddCategorizedArticle(a)
+	}
+	hub := NewHub(articles)
+	t.Cleanup(hub.Close)
+
+	first := CursorOf(article("000000", base)).EventID()
+	tests := []struct {
+		name        string
+		query       cache.Query
+		lastEventID string
+		want        int
+	}{
+		{"no event ID", cache.Query{}, "", 0},
+		{"pages past the page size", cache.Query{}, first, total - 1},
+		{"filtered", cache.Query{Category: "technology"}, first, (total - 1) / 2},
+		{"caught up", cache.Query{}, CursorOf(article(fmt.Sprintf("%06d", total-1), base.Add(time.Duration(total-1)*time.Second))).EventID(), 0},
+	}
+	for _, tt := range tests {
+		feed, err := hub.Open(tt.query, tt.lastEventID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := replayed(t, feed)
+		if len(ids) != tt.want {
+			t.Errorf("%s: replayed %d articles, want %d", tt.name, len(ids), tt.want)
+		}
+		for i := 1; i < len(ids); i++ {
+			if ids[i] <= ids[i-1] {
+				t.Errorf("%s: replay out of order at %d: %s after %s", tt.name, i, ids[i], ids[i-1])
+				break
+			}
+		}
+		if again := replayed(t, feed); len(again) != 0 {
+			t.Errorf("%s: a second Replay sent %d articles", tt.name, len(again))
+		}
+		feed.Close()
+	}
+}
+
+func TestFeedSkipsLiveEventsAlreadyReplayed(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	articles := cache.New(24 * time.Hour)
+	t.Cleanup(articles.Close)
+	hub := NewHub(articles)
+	t.Cleanup(hub.Close)
+
+	articles.AddCategorizedArticle(article("a", base))
+	feed, err := hub.Open(cache.Query{}, CursorOf(article("a", base)).EventID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer feed.Close()
+
+	// Published after the subscription but before the replay reads the
+	// cache: it arrives both ways and must only be sent once.
+	b := article("b", base.Add(time.Second))
+	articles.AddCategorizedArticle(b)
+	hub.Notify(context.Background(), b)
+
+	if ids := replayed(t, feed); len(ids) != 1 {
+		t.Fatalf("replayed %v, want one article", ids)
+	}
+	if _, ok := feed.Next(<-feed.Events()); ok {
+		t.Error("live copy of a replayed article was sent again")
+	}
+
+	c := article("c", base.Add(2*time.Second))
+	hub.Notify(context.Background(), c)
+	if id, ok := feed.Next(<-feed.Events()); !ok || id != CursorOf(c).EventID() {
+		t.Errorf("new live article: id %q, ok %v", id, ok)
+	}
+}
+
+func TestFeedReplayStopsOnSendError(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	articles := cache.New(24 * time.Hour)
+	t.Cleanup(articles.Close)
+	for i := 0; i < 3; i++ {
+		articles.AddCategorizedArticle(article(fmt.Sprint(i), base.Add(time.Duration(i+1)*time.Second)))
+	}
+	hub := NewHub(articles)
+	t.Cleanup(hub.Close)
+
+	feed, err := hub.Open(cache.Query{}, Cursor{ProcessedAt: base, Hash: "0"}.EventID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer feed.Close()
+
+	gone := errors.New("client went away")
+	sent := 0
+	err = feed.Replay(func(models.CategorizedArticle) error {
+		sent++
+		return gone
+	})
+	if !errors.Is(err, gone) || sent != 1 {
+		t.Errorf("Replay = %v after %d sends, want the send error after 1", err, sent)
+	}
+	if _, err := hub.Open(cache.Query{}, "bogus"); !errors.Is(err, ErrInvalidEventID) {
+		t.Errorf("Open with a bad event ID: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+const (
+	maxSubscribers = 200
+	bufferSize     = 256
+	replayPageSize = 500
+	idHashLength   = 16
+)
+
+var (
+	ErrTooManySubscribers = errors.New("too many live feed subscribers")
+	ErrClosed             = errors.New("live feed is shutting down")
+	ErrInvalidEventID     = errors.New("invalid event ID")
+)
+
+// Hub fans categorized articles out to live feed subscribers. It is a
+// notifier, so it sees every article as soon as a batch is processed.
+type Hub struct {
+	articles    *cache.Cache
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	mu          sync.Mutex
+}
+
+// Subscription receives every published article until it is closed. A
+// subscriber that falls a full buffer behind is dropped; it can reconnect
+// and resume from its last event ID.
+type Subscription struct {
+	hub    *Hub
+	events chan models.CategorizedArticle
+}
+
+func NewHub(articles *cache.Cache) *Hub {
+	return &Hub{
+		articles:    articles,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) Name() string {
+	return "live feed"
+}
+
+func (h *Hub) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- article:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return nil
+}
+
+func (h *Hub) Subscribe() (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if len(h.subscribers) >= maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		events: make(chan models.CategorizedArticle, bufferSize),
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Close ends every subscription so streaming handlers return and the HTTP
+// server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Events is closed when the subscriber is dropped or the hub shuts down.
+func (s *Subscription) Events() <-chan models.CategorizedArticle {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if _, ok := s.hub.subscribers[s]; ok {
+		delete(s.hub.subscribers, s)
+		close(s.events)
+	}
+}
+
+// Cursor identifies an article's position in the feed: when it was
+// processed, then its hash to order articles processed together.
+type Cursor struct {
+	ProcessedAt time.Time
+	Hash        string
+}
+
+func CursorOf(article models.CategorizedArticle) Cursor {
+	hash := article.Hash
+	if len(hash) > idHashLength {
+		hash = hash[:idHashLength]
+	}
+	return Cursor{ProcessedAt: article.ProcessedAt, Hash: hash}
+}
+
+// EventID formats the cursor as "<unix nanoseconds>-<hash prefix>".
+func (c Cursor) EventID() string {
+	return fmt.Sprintf("%d-%s", c.ProcessedAt.UnixNano(), c.Hash)
+}
+
+// Before reports whether article comes after the cursor in the feed.
+func (c Cursor) Before(article models.CategorizedArticle) bool {
+	next := CursorOf(article)
+	if !next.ProcessedAt.Equal(c.ProcessedAt) {
+		return next.ProcessedAt.After(c.ProcessedAt)
+	}
+	return next.Hash > c.Hash
+}
+
+func ParseEventID(id string) (Cursor, error) {
+	nanos, hash, ok := strings.Cut(id, "-")
+	value, err := strconv.ParseInt(nanos, 10, 64)
+	if !ok || err != nil || hash == "" {
+		return Cursor{}, fmt.Errorf("%w %q", ErrInvalidEventID, id)
+	}
+	return Cursor{ProcessedAt: time.Unix(0, value), Hash: hash}, nil
+}
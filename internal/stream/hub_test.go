@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func article(hash string, processedAt time.Time) models.CategorizedArticle {
+	return models.CategorizedArticle{
+		Article:     models.Article{Hash: hash, Title: hash, Category: "technology"},
+		ProcessedAt: processedAt,
+	}
+}
+
+func TestEventIDRoundTrip(t *testing.T) {
+	at := time.Unix(1700000000, 123456789)
+
+	tests := []struct {
+		hash string
+		want string
+	}{
+		{"abc", "1700000000123456789-abc"},
+		{"0123456789abcdef0123", "1700000000123456789-0123456789abcdef"},
+	}
+	for _, tt := range tests {
+		cursor := CursorOf(article(tt.hash, at))
+		if got := cursor.EventID(); got != tt.want {
+			t.Errorf("EventID(%q) = %q, want %q", tt.hash, got, tt.want)
+		}
+		parsed, err := ParseEventID(tt.want)
+		if err != nil || !parsed.ProcessedAt.Equal(cursor.ProcessedAt) || parsed.Hash != cursor.Hash {
+			t.Errorf("ParseEventID(%q) = %+v, %v, want %+v", tt.want, parsed, err, cursor)
+		}
+	}
+}
+
+func TestParseEventIDRejectsMalformed(t *testing.T) {
+	for _, id := range []string{"", "123", "123-", "abc-def", "-abc"} {
+		if _, err := ParseEventID(id); !errors.Is(err, ErrInvalidEventID) {
+			t.Errorf("ParseEventID(%q) err = %v, want ErrInvalidEventID", id, err)
+		}
+	}
+}
+
+func TestCursorBefore(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	cursor := Cursor{ProcessedAt: at, Hash: "bbb"}
+
+	tests := []struct {
+		name    string
+		article models.CategorizedArticle
+		want    bool
+	}{
+		{"later", article("aaa", at.Add(time.Nanosecond)), true},
+		{"earlier", article("zzz", at.Add(-time.Nanosecond)), false},
+		{"same time, higher hash", article("ccc", at), true},
+		{"same time, lower hash", article("aaa", at), false},
+		{"same article", article("bbb", at), false},
+	}
+	for _, tt := range tests {
+		if got := cursor.Before(tt.article); got != tt.want {
+			t.Errorf("%s: Before = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	hub := NewHub(articles)
+
+	slow, err := hub.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= bufferSize; i++ {
+		hub.Notify(context.Background(), article("a", time.Now()))
+	}
+
+	drained := 0
+	for range slow.Events() {
+		drained++
+	}
+	if drained != bufferSize {
+		t.Errorf("slow subscriber received %d events before being dropped, want %d", drained, bufferSize)
+	}
+	slow.Close()
+
+	hub.Close()
+	if _, err := hub.Subscribe(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/quota"
+)
+
+const maxAdminListedChats = 50
+
+// AdminBackend exposes the aggregator state operators need from Telegram.
+type AdminBackend interface {
+	CacheStats() map[string]interface{}
+	SourceHealth() []models.SourceHealth
+	SetSourcePaused(name string, paused bool) error
+	AIUsage() models.AIUsage
+}
+
+func (b *Bot) SetAdmins(userIDs []int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.admins = make(map[int64]bool, len(userIDs))
+	for _, id := range userIDs {
+		b.admins[id] = true
+	}
+}
+
+func (b *Bot) SetAdminBackend(backend AdminBackend) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.adminBackend = backend
+}
+
+func (b *Bot) isAdmin(userID int64) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.admins[userID]
+}
+
+// handleAdmin serves /admin to allowlisted operators. Everyone else gets the
+// usual unknown-command reply so the command isn't advertised.
+func (b *Bot) handleAdmin(userID, chatID int64, text string) {
+	b.mu.RLock()
+	backend := b.adminBackend
+	b.mu.RUnlock()
+
+	if !b.isAdmin(userID) || backend == nil {
+		b.handleUnknownCommand(chatID)
+		return
+	}
+
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		b.sendMessage(chatID, adminUsage)
+		return
+	}
+
+	switch parts[1] {
+	case "stats":
+		b.sendMessage(chatID, b.formatAdminStats(backend))
+	case "sources":
+		b.sendMessage(chatID, formatSourceHealth(backend.SourceHealth()))
+	case "pause", "resume":
+		if len(parts) < 3 {
+			b.sendMessage(chatID, "Usage: /admin pause [source] or /admin resume [source]")
+			return
+		}
+		paused := parts[1] == "pause"
+		if err := backend.SetSourcePaused(parts[2], paused); err != nil {
+			b.sendMessage(chatID, html.EscapeString(err.Error())+". See /admin sources.")
+			return
+		}
+		b.sendMessage(chatID, fmt.Sprintf("Source %s %sd.", html.EscapeString(parts[2]), parts[1]))
+	case "broadcast":
+		_, message, _ := strings.Cut(text, "broadcast")
+		message = strings.TrimSpace(message)
+		if message == "" {
+			b.sendMessage(chatID, "Usage: /admin broadcast [message]")
+			return
+		}
+		sent := b.broadcast("📢 " + html.EscapeString(message))
+		b.sendMessage(chatID, fmt.Sprintf("Broadcast queued for %d chats.", sent))
+	case "users":
+		b.sendMessage(chatID, b.formatAdminChats())
+	case "tier":
+		b.handleAdminTier(chatID, parts[2:])
+	case "email":
+		b.handleAdminEmail(chatID, text)
+	default:
+		b.sendMessage(chatID, adminUsage)
+	}
+}
+
+const adminUsage = `Admin commands:
+/admin stats - Cache, queue, subscription and AI spend figures
+/admin sources - Fetch health for each news source
+/admin pause [source] / /admin resume [source] - Stop or restart fetching a source
+/admin broadcast [message] - Send a message to every subscribed chat
+/admin users - Chats with alerts
+/admin tier [chat id] [free|pro|admin] - Show or change a user's or chat's plan
+/admin email add|remove|list - Manage email subscribers`
+
+func (b *Bot) handleAdminTier(chatID int64, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		b.sendMessage(chatID, "Usage: /admin tier [chat id] [free|pro|admin]")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.sendMessage(chatID, "Chat IDs are numbers, e.g. 123456789 for a user or -100123456789 for a group. See /admin users.")
+		return
+	}
+
+	if len(args) == 1 {
+		b.sendMessage(chatID, fmt.Sprintf("%d is on the %s plan.", id, b.tier(id)))
+		return
+	}
+
+	b.mu.RLock()
+	quotas := b.quotas
+	b.mu.RUnlock()
+
+	tier := strings.ToLower(args[1])
+	if !quota.ValidTier(tier) {
+		b.sendMessage(chatID, "Unknown plan. Use free, pro or admin.")
+		return
+	}
+	if err := quotas.SetTier(id, tier); err != nil {
+		log.Printf("Failed to save tier for %d: %v", id, err)
+		b.sendMessage(chatID, "The plan was changed but could not be saved to disk.")
+		return
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("%d is now on the %s plan.", id, tier))
+}
+
+// broadcast sends text to every chat with alerts whose delivery is not
+// disabled, returning how many chats it was queued for.
+func (b *Bot) broadcast(text string) int {
+	b.mu.RLock()
+	var chats []int64
+	for chatID := range b.chatAlerts {
+		if !b.settingsLocked(chatID).Disabled {
+			chats = append(chats, chatID)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, chatID := range chats {
+		b.sendMessage(chatID, text)
+	}
+	return len(chats)
+}
+
+func (b *Bot) formatAdminStats(backend AdminBackend) string {
+	b.mu.RLock()
+	chats, alerts, disabled := len(b.chatAlerts), 0, 0
+	for chatID, chatAlerts := range b.chatAlerts {
+		alerts += len(chatAlerts)
+		if b.settingsLocked(chatID).Disabled {
+			disabled++
+		}
+	}
+	deliveries := len(b.deliveries)
+	b.mu.RUnlock()
+
+	stats := backend.CacheStats()
+	usage := backend.AIUsage()
+
+	var sb strings.Builder
+	sb.WriteString("📊 <b>Stats</b>\n\n")
+	sb.WriteString(fmt.Sprintf("Cache: %v articles, %v categorized (retention %v)\n",
+		stats["total_articles"], stats["categorized"], stats["retention"]))
+	sb.WriteString(fmt.Sprintf("Chats: %d (%d disabled), alerts: %d\n", chats, disabled, alerts))
+	sb.WriteString(fmt.Sprintf("Recent deliveries: %d, send queue: %d\n\n", deliveries, b.QueueLength()))
+	sb.WriteString(fmt.Sprintf("AI since %s: %d requests, %d prompt + %d completion tokens, %d embedding tokens\n",
+		usage.Since.Format("Jan 2 15:04"), usage.Requests, usage.PromptTokens, usage.CompletionTokens, usage.EmbeddingTokens))
+	sb.WriteString(fmt.Sprintf("Estimated AI spend: $%.4f", usage.EstimatedCostUSD))
+	return sb.String()
+}
+
+func formatSourceHealth(sources []models.SourceHealth) string {
+	var sb strings.Builder
+	sb.WriteString("📡 <b>Sources</b>\n")
+	for _, source := range sources {
+		status := "✅"
+		switch {
+		case source.Paused:
+			status = "⏸️"
+		case source.LastError != "":
+			status = "⚠️"
+		case source.Fetches == 0:
+			status = "⏳"
+		}
+
+		sb.WriteString(fmt.Sprintf("\n%s <b>%s</b> - %d fetches, %d failed, %d articles",
+			status, html.EscapeString(source.Name), source.Fetches, source.Failures, source.Articles))
+		if !source.LastSuccess.IsZero() {
+			sb.WriteString(fmt.Sprintf("\nLast success: %s", timeAgo(source.LastSuccess)))
+		}
+		if source.LastError != "" {
+			sb.WriteString("\nLast error: " + html.EscapeString(source.LastError))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (b *Bot) formatAdminChats() string {
+	type chatSummary struct {
+		chatID   int64
+		alerts   int
+		settings models.UserSettings
+		created  time.Time
+	}
+
+	b.mu.RLock()
+	summaries := make([]chatSummary, 0, len(b.chatAlerts))
+	for chatID, alerts := range b.chatAlerts {
+		summary := chatSummary{chatID: chatID, alerts: len(alerts), settings: b.settingsLocked(chatID)}
+		for _, alert := range alerts {
+			if summary.created.IsZero() || alert.CreatedAt.Before(summary.created) {
+				summary.created = alert.CreatedAt
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	b.mu.RUnlock()
+
+	if len(summaries) == 0 {
+		return "No chats have alerts yet."
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].created.Before(summaries[j].created)
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👥 <b>%d chats with alerts</b>\n", len(summaries)))
+	for i, summary := range summaries {
+		if i == maxAdminListedChats {
+			sb.WriteString(fmt.Sprintf("\n…and %d more", len(summaries)-maxAdminListedChats))
+			break
+		}
+
+		kind := "user"
+		if summary.chatID < 0 {
+			kind = "group/channel"
+		}
+		line := fmt.Sprintf("\n%d (%s, %s) - %d alerts, since %s", summary.chatID, kind, b.tier(summary.chatID), summary.alerts, summary.created.Format("Jan 2"))
+		if summary.settings.Disabled {
+			line += " - disabled: " + html.EscapeString(summary.settings.DisabledReason)
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,493 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+)
+
+const defaultAlertName = "default"
+
+var (
+	ErrAlertExists       = errors.New("alert name already in use")
+	ErrTooManyAlerts     = errors.New("too many alerts")
+	ErrTopicsUnavailable = errors.New("topic alerts are not available right now")
+)
+
+func (b *Bot) handleAlertCommand(ctx context.Context, userID, chatID int64, text string) {
+	parts := splitArgs(text)
+	if len(parts) == 1 || (len(parts) <= 3 && parts[1] == "new") {
+		name := ""
+		if len(parts) == 3 {
+			name = parts[2]
+		}
+		b.handleAlertWizard(userID, chatID, name)
+		return
+	}
+	if len(parts) < 3 {
+		b.sendMessage(chatID, "Invalid alert format. Use: /alert add [name] category=politics keywords=bitcoin,crypto")
+		return
+	}
+
+	switch parts[1] {
+	case "set":
+		b.handleAlertSet(ctx, userID, chatID, defaultAlertName, parts[2:], true)
+	case "add":
+		if len(parts) < 4 {
+			b.sendMessage(chatID, "Invalid alert format. Use: /alert add [name] category=politics keywords=bitcoin,crypto")
+			return
+		}
+		b.handleAlertSet(ctx, userID, chatID, parts[2], parts[3:], false)
+	case "rule":
+		name, rule := ruleText(text)
+		if rule == "" {
+			b.sendMessage(chatID, "Invalid alert format. Use: /alert rule [name] category:crypto AND (bitcoin OR btc)")
+			return
+		}
+		b.handleAlertRule(userID, chatID, name, rule)
+	case "remove", "delete":
+		b.handleAlertRemove(chatID, parts[2])
+	case "pause":
+		b.handleAlertToggle(chatID, parts[2], false)
+	case "resume":
+		b.handleAlertToggle(chatID, parts[2], true)
+	default:
+		b.sendMessage(chatID, "Unknown alert command. Use /alert new, add, set, rule, remove, pause or resume.")
+	}
+}
+
+func (b *Bot) handleAlertSet(ctx context.Context, userID, chatID int64, name string, args []string, replace bool) {
+	if !validAlertName(name) {
+		b.sendMessage(chatID, "Alert names may only contain letters, digits, '-' and '_' (max 32 characters).")
+		return
+	}
+
+	alert := &models.UserAlert{
+		UserID:  userID,
+		ChatID:  chatID,
+		Name:    name,
+		Enabled: true,
+	}
+
+	if err := parseAlertOptions(args, alert); err != nil {
+		b.sendMessage(chatID, "Invalid alert options: "+html.EscapeString(err.Error()))
+		return
+	}
+
+	if (alert.Topic != "" || alert.DigestOverview) && !b.limits(chatID).AI {
+		b.sendMessage(chatID, b.upgradeMessage(chatID, "Topic alerts and AI digest overviews"))
+		return
+	}
+
+	if err := b.embedTopic(ctx, alert); err != nil {
+		if errors.Is(err, ErrTopicsUnavailable) {
+			b.sendMessage(chatID, "Topic alerts are not available right now.")
+			return
+		}
+		log.Printf("Failed to embed alert topic for user %d: %v", userID, err)
+		b.sendMessage(chatID, "Failed to process your topic. Please try again later.")
+		return
+	}
+
+	if err := b.saveAlert(alert, replace); err != nil {
+		b.sendMessage(chatID, b.saveAlertErrorMessage(alert, err))
+		return
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("Alert configured! 🎯\n\n%s", formatAlertSummary(alert)))
+}
+
+// embedTopic computes the embedding topic alerts are matched against.
+func (b *Bot) embedTopic(ctx context.Context, alert *models.UserAlert) error {
+	if alert.Topic == "" {
+		return nil
+	}
+
+	b.mu.RLock()
+	embedder := b.embedder
+	b.mu.RUnlock()
+
+	if embedder == nil {
+		return ErrTopicsUnavailable
+	}
+
+	embedding, err := embedder.Embed(ctx, alert.Topic)
+	if err != nil {
+		return err
+	}
+	alert.TopicEmbedding = embedding
+	return nil
+}
+
+func (b *Bot) handleAlertRule(userID, chatID int64, name, rule string) {
+	if !validAlertName(name) {
+		b.sendMessage(chatID, "Alert names may only contain letters, digits, '-' and '_' (max 32 characters).")
+		return
+	}
+
+	if _, err := rules.Parse(rule); err != nil {
+		b.sendMessage(chatID, formatRuleError(rule, err))
+		return
+	}
+
+	alert := &models.UserAlert{
+		UserID:  userID,
+		ChatID:  chatID,
+		Name:    name,
+		Rule:    rule,
+		Enabled: true,
+	}
+
+	if err := b.saveAlert(alert, true); err != nil {
+		b.sendMessage(chatID, b.saveAlertErrorMessage(alert, err))
+		return
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("Alert rule saved! 🎯\n\n%s", formatAlertSummary(alert)))
+}
+
+func (b *Bot) handleAlertRemove(chatID int64, ref string) {
+	b.mu.Lock()
+	alert := b.findAlertLocked(chatID, ref)
+	if alert != nil {
+		b.removeAlertLocked(alert)
+	}
+	b.mu.Unlock()
+
+	if alert == nil {
+		b.sendMessage(chatID, fmt.Sprintf("No alert named %s. Use /list to see your alerts.", html.EscapeString(ref)))
+		return
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("Alert %s removed. 🗑️", html.EscapeString(ref)))
+}
+
+func (b *Bot) handleAlertToggle(chatID int64, ref string, enabled bool) {
+	b.mu.Lock()
+	alert := b.findAlertLocked(chatID, ref)
+	if alert != nil {
+		alert.Enabled = enabled
+	}
+	b.mu.Unlock()
+
+	if alert == nil {
+		b.sendMessage(chatID, fmt.Sprintf("No alert named %s. Use /list to see your alerts.", html.EscapeString(ref)))
+		return
+	}
+
+	if enabled {
+		b.sendMessage(chatID, fmt.Sprintf("Alert %s resumed. ▶️", html.EscapeString(alert.Name)))
+	} else {
+		b.sendMessage(chatID, fmt.Sprintf("Alert %s paused. ⏸️", html.EscapeString(alert.Name)))
+	}
+}
+
+func (b *Bot) handleListAlerts(chatID int64) {
+	b.mu.RLock()
+	alerts := make([]models.UserAlert, 0, len(b.chatAlerts[chatID]))
+	for _, alert := range b.chatAlerts[chatID] {
+		alerts = append(alerts, *alert)
+	}
+	b.mu.RUnlock()
+
+	if len(alerts) == 0 {
+		b.sendMessage(chatID, "No alerts configured. Use /alert add to create one.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Your current alerts: 📋\n")
+
+	for _, alert := range alerts {
+		sb.WriteString("\n")
+		sb.WriteString(formatAlertSummary(&alert))
+		sb.WriteString("\n")
+	}
+
+	b.sendMessage(chatID, sb.String())
+}
+
+// saveAlert stores alert under its name in its chat, replacing an existing
+// alert with the same name (keeping its ID) when replace is set.
+func (b *Bot) saveAlert(alert *models.UserAlert, replace bool) error {
+	limit := b.limits(alert.ChatID).Alerts
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, existing := range b.chatAlerts[alert.ChatID] {
+		if !strings.EqualFold(existing.Name, alert.Name) {
+			continue
+		}
+		if !replace {
+			return ErrAlertExists
+		}
+
+		alert.ID = existing.ID
+		alert.CreatedAt = existing.CreatedAt
+		b.chatAlerts[alert.ChatID][i] = alert
+		return nil
+	}
+
+	if len(b.chatAlerts[alert.ChatID]) >= limit {
+		return ErrTooManyAlerts
+	}
+
+	b.nextAlertID++
+	alert.ID = fmt.Sprintf("A%d", b.nextAlertID)
+	alert.CreatedAt = time.Now()
+	b.chatAlerts[alert.ChatID] = append(b.chatAlerts[alert.ChatID], alert)
+	return nil
+}
+
+func (b *Bot) saveAlertErrorMessage(alert *models.UserAlert, err error) string {
+	switch {
+	case errors.Is(err, ErrAlertExists):
+		return fmt.Sprintf("An alert named %s already exists. Remove it first or pick another name.", html.EscapeString(alert.Name))
+	case errors.Is(err, ErrTooManyAlerts):
+		return fmt.Sprintf("This chat already has the %d alerts its %s plan allows. Remove one before adding another.",
+			b.limits(alert.ChatID).Alerts, b.tier(alert.ChatID))
+	default:
+		return "Failed to save alert. Please try again later."
+	}
+}
+
+func (b *Bot) findAlertLocked(chatID int64, ref string) *models.UserAlert {
+	for _, alert := range b.chatAlerts[chatID] {
+		if alert.Matches(ref) {
+			return alert
+		}
+	}
+	return nil
+}
+
+func parseAlertOptions(args []string, alert *models.UserAlert) error {
+	for _, part := range args {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q (quote values that contain spaces)", part)
+		}
+
+		switch key {
+		case "category", "categories":
+			alert.Categories = append(alert.Categories, splitList(value)...)
+		case "keywords":
+			alert.Keywords = append(alert.Keywords, cleanList(strings.Split(value, ","), false)...)
+		case "tags":
+			alert.Tags = append(alert.Tags, cleanList(strings.Split(value, ","), false)...)
+		case "language", "languages":
+			alert.Languages = append(alert.Languages, splitList(value)...)
+		case "topic":
+			alert.Topic = value
+		case "threshold":
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil || threshold <= 0 || threshold > 1 {
+				return fmt.Errorf("threshold must be a number between 0 and 1, e.g. threshold=0.6")
+			}
+			alert.TopicThreshold = threshold
+		case "min_confidence":
+			confidence, err := parseConfidence(value)
+			if err != nil {
+				return err
+			}
+			alert.MinConfidence = confidence
+		case "sentiment":
+			for _, sentiment := range splitList(value) {
+				if sentiment != "positive" && sentiment != "negative" && sentiment != "neutral" {
+					return fmt.Errorf("sentiment must be positive, negative or neutral, got %q", sentiment)
+				}
+				alert.Sentiments = append(alert.Sentiments, sentiment)
+			}
+		case "sources":
+			alert.Sources = append(alert.Sources, splitList(value)...)
+		case "exclude_sources":
+			alert.ExcludeSources = append(alert.ExcludeSources, splitList(value)...)
+		case "delivery":
+			alert.Delivery = value
+		case "at":
+			alert.DigestAt = value
+		case "overview":
+			overview, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("overview must be true or false, got %q", value)
+			}
+			alert.DigestOverview = overview
+		case "max_age":
+			maxAge, err := time.ParseDuration(value)
+			if err != nil || maxAge <= 0 {
+				return fmt.Errorf("max_age must be a duration such as 30m or 2h, got %q", value)
+			}
+			alert.MaxAge = maxAge
+		default:
+			return fmt.Errorf("unknown option %q; see /help for the supported options", key)
+		}
+	}
+
+	return checkDelivery(alert)
+}
+
+// checkDelivery validates an alert's delivery settings and fills in the
+// default daily digest time.
+func checkDelivery(alert *models.UserAlert) error {
+	switch alert.Delivery {
+	case "", models.DeliveryInstant, models.DeliveryHourly, models.DeliveryDaily:
+	default:
+		return fmt.Errorf("delivery must be instant, hourly or daily, got %q", alert.Delivery)
+	}
+	if alert.DigestAt != "" {
+		if _, err := time.Parse("15:04", alert.DigestAt); err != nil {
+			return fmt.Errorf("at must be a 24-hour time such as 08:00, got %q", alert.DigestAt)
+		}
+	}
+
+	if alert.Delivery == models.DeliveryDaily && alert.DigestAt == "" {
+		alert.DigestAt = defaultDigestAt
+	}
+	if alert.Delivery != models.DeliveryDaily && alert.DigestAt != "" {
+		return fmt.Errorf("at= only applies to delivery=daily")
+	}
+	alert.LastDigestAt = time.Now()
+
+	return nil
+}
+
+// parseConfidence accepts either a fraction (0.8) or a percentage (80 or 80%).
+func parseConfidence(value string) (float64, error) {
+	percent := strings.HasSuffix(value, "%")
+	confidence, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("min_confidence must be a number such as 0.8 or 80%%, got %q", value)
+	}
+
+	if percent || confidence > 1 {
+		confidence /= 100
+	}
+	if confidence < 0 || confidence > 1 {
+		return 0, fmt.Errorf("min_confidence must be between 0 and 1 (or 0%% and 100%%), got %q", value)
+	}
+
+	return confidence, nil
+}
+
+func splitList(value string) []string {
+	return cleanList(strings.Split(value, ","), true)
+}
+
+// cleanList trims the items of a list, optionally lowercasing them, and
+// drops empty ones.
+func cleanList(items []string, lower bool) []string {
+	var values []string
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if lower {
+			item = strings.ToLower(item)
+		}
+		if item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func validAlertName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatAlertSummary(alert *models.UserAlert) string {
+	status := map[bool]string{true: "Enabled", false: "Paused"}[alert.Enabled]
+
+	var summary string
+	if alert.Rule != "" {
+		summary = fmt.Sprintf("<b>%s</b> (%s) - %s\nRule: %s\nLanguages: %s",
+			html.EscapeString(alert.Name), alert.ID, status,
+			html.EscapeString(alert.Rule), html.EscapeString(formatLanguages(alert)))
+	} else {
+		summary = fmt.Sprintf("<b>%s</b> (%s) - %s\nCategories: %s\nKeywords: %s\nTags: %s\nTopic: %s\nLanguages: %s",
+			html.EscapeString(alert.Name), alert.ID, status,
+			formatList(alert.Categories), formatList(alert.Keywords), formatList(alert.Tags),
+			html.EscapeString(formatTopic(alert)), html.EscapeString(formatLanguages(alert)))
+	}
+
+	if filters := formatFilters(alert); filters != "" {
+		summary += "\nFilters: " + filters
+	}
+	summary += "\nDelivery: " + formatDelivery(alert)
+	return summary
+}
+
+func formatDelivery(alert *models.UserAlert) string {
+	var delivery string
+	switch alert.Delivery {
+	case models.DeliveryHourly:
+		delivery = "hourly digest"
+	case models.DeliveryDaily:
+		delivery = "daily digest at " + alert.DigestAt
+	default:
+		return "instant"
+	}
+
+	if alert.DigestOverview {
+		delivery += " with AI overview"
+	}
+	return delivery
+}
+
+func formatFilters(alert *models.UserAlert) string {
+	var filters []string
+	if alert.MinConfidence > 0 {
+		filters = append(filters, fmt.Sprintf("confidence ≥ %.0f%%", alert.MinConfidence*100))
+	}
+	if len(alert.Sentiments) > 0 {
+		filters = append(filters, "sentiment "+formatList(alert.Sentiments))
+	}
+	if len(alert.Sources) > 0 {
+		filters = append(filters, "sources "+formatList(alert.Sources))
+	}
+	if len(alert.ExcludeSources) > 0 {
+		filters = append(filters, "excluding "+formatList(alert.ExcludeSources))
+	}
+	if alert.MaxAge > 0 {
+		filters = append(filters, "max age "+alert.MaxAge.String())
+	}
+	return strings.Join(filters, "; ")
+}
+
+func formatList(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return html.EscapeString(strings.Join(values, ", "))
+}
+
+func formatLanguages(alert *models.UserAlert) string {
+	if len(alert.Languages) == 0 {
+		return "any"
+	}
+	return strings.Join(alert.Languages, ", ")
+}
+
+func formatTopic(alert *models.UserAlert) string {
+	if alert.Topic == "" {
+		return "-"
+	}
+	if alert.TopicThreshold > 0 {
+		return fmt.Sprintf("%q (threshold %.2f)", alert.Topic, alert.TopicThreshold)
+	}
+	return fmt.Sprintf("%q", alert.Topic)
+}
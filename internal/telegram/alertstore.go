@@ -0,0 +1,262 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+)
+
+var (
+	ErrAlertNotFound = errors.New("alert not found")
+	ErrInvalidAlert  = errors.New("invalid alert")
+	ErrNotInPlan     = errors.New("not included in the chat's plan")
+)
+
+// AlertSpec describes an alert created outside Telegram, e.g. through the
+// HTTP API. An alert has either a Rule (an /alert rule expression) or
+// filters, validated like the /alert add options; the delivery settings
+// apply to both.
+type AlertSpec struct {
+	ChatID  int64
+	Name    string
+	Rule    string
+	Enabled bool
+
+	Categories     []string
+	Keywords       []string
+	Tags           []string
+	Languages      []string
+	Topic          string
+	TopicThreshold float64
+	MinConfidence  float64
+	Sentiments     []string
+	Sources        []string
+	ExcludeSources []string
+	MaxAge         time.Duration
+
+	Delivery       string
+	DigestAt       string
+	DigestOverview bool
+}
+
+func (spec AlertSpec) hasFilters() bool {
+	lists := [][]string{spec.Categories, spec.Keywords, spec.Tags, spec.Languages, spec.Sentiments, spec.Sources, spec.ExcludeSources}
+	for _, list := range lists {
+		if len(list) > 0 {
+			return true
+		}
+	}
+	return spec.Topic != "" || spec.TopicThreshold != 0 || spec.MinConfidence != 0 || spec.MaxAge != 0
+}
+
+// applyFilters copies the spec's filters onto alert, normalising lists the
+// same way /alert add does.
+func (spec AlertSpec) applyFilters(alert *models.UserAlert) error {
+	alert.Categories = cleanList(spec.Categories, true)
+	alert.Keywords = cleanList(spec.Keywords, false)
+	alert.Tags = cleanList(spec.Tags, false)
+	alert.Languages = cleanList(spec.Languages, true)
+	alert.Sources = cleanList(spec.Sources, true)
+	alert.ExcludeSources = cleanList(spec.ExcludeSources, true)
+
+	for _, sentiment := range cleanList(spec.Sentiments, true) {
+		if sentiment != "positive" && sentiment != "negative" && sentiment != "neutral" {
+			return fmt.Errorf("sentiment must be positive, negative or neutral, got %q", sentiment)
+		}
+		alert.Sentiments = append(alert.Sentiments, sentiment)
+	}
+
+	if spec.TopicThreshold < 0 || spec.TopicThreshold > 1 {
+		return fmt.Errorf("topic_threshold must be between 0 and 1")
+	}
+	if spec.MinConfidence < 0 || spec.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be between 0 and 1")
+	}
+	if spec.MaxAge < 0 {
+		return fmt.Errorf("max_age must be positive")
+	}
+	alert.Topic = spec.Topic
+	alert.TopicThreshold = spec.TopicThreshold
+	alert.MinConfidence = spec.MinConfidence
+	alert.MaxAge = spec.MaxAge
+	return nil
+}
+
+// Alerts returns copies of a chat's alerts, or of every alert when chatID
+// is 0, ordered by chat and then creation.
+func (b *Bot) Alerts(chatID int64) []models.UserAlert {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var alerts []models.UserAlert
+	for id, chatAlerts := range b.chatAlerts {
+		if chatID != 0 && id != chatID {
+			continue
+		}
+		for _, alert := range chatAlerts {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].ChatID != alerts[j].ChatID {
+			return alerts[i].ChatID < alerts[j].ChatID
+		}
+		return alerts[i].CreatedAt.Before(alerts[j].CreatedAt)
+	})
+	return alerts
+}
+
+func (b *Bot) Alert(id string) (models.UserAlert, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if alert := b.alertByIDLocked(id); alert != nil {
+		return *alert, true
+	}
+	return models.UserAlert{}, false
+}
+
+func (b *Bot) CreateAlert(ctx context.Context, spec AlertSpec) (models.UserAlert, error) {
+	alert, err := b.buildAlert(ctx, spec)
+	if err != nil {
+		return models.UserAlert{}, err
+	}
+	if err := b.saveAlert(alert, false); err != nil {
+		return models.UserAlert{}, err
+	}
+	return *alert, nil
+}
+
+// UpdateAlert replaces the options of an existing alert, keeping its ID and
+// chat. An empty name keeps the current one.
+func (b *Bot) UpdateAlert(ctx context.Context, id string, spec AlertSpec) (models.UserAlert, error) {
+	existing, ok := b.Alert(id)
+	if !ok {
+		return models.UserAlert{}, ErrAlertNotFound
+	}
+
+	spec.ChatID = existing.ChatID
+	if spec.Name == "" {
+		spec.Name = existing.Name
+	}
+	alert, err := b.buildAlert(ctx, spec)
+	if err != nil {
+		return models.UserAlert{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	index := -1
+	for i, other := range b.chatAlerts[alert.ChatID] {
+		switch {
+		case other.ID == existing.ID:
+			index = i
+		case strings.EqualFold(other.Name, alert.Name):
+			return models.UserAlert{}, ErrAlertExists
+		}
+	}
+	if index < 0 {
+		return models.UserAlert{}, ErrAlertNotFound
+	}
+
+	alert.ID = existing.ID
+	alert.UserID = existing.UserID
+	alert.CreatedAt = existing.CreatedAt
+	b.chatAlerts[alert.ChatID][index] = alert
+	return *alert, nil
+}
+
+func (b *Bot) DeleteAlert(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	alert := b.alertByIDLocked(id)
+	if alert == nil {
+		return ErrAlertNotFound
+	}
+
+	b.removeAlertLocked(alert)
+	return nil
+}
+
+// removeAlertLocked drops alert from its chat along with any digest it has
+// collected but not yet sent.
+func (b *Bot) removeAlertLocked(alert *models.UserAlert) {
+	alerts := b.chatAlerts[alert.ChatID]
+	for i, other := range alerts {
+		if other == alert {
+			b.chatAlerts[alert.ChatID] = append(alerts[:i:i], alerts[i+1:]...)
+			break
+		}
+	}
+	if len(b.chatAlerts[alert.ChatID]) == 0 {
+		delete(b.chatAlerts, alert.ChatID)
+	}
+	delete(b.pendingDigests, alert.ID)
+}
+
+func (b *Bot) alertByIDLocked(id string) *models.UserAlert {
+	for _, alerts := range b.chatAlerts {
+		for _, alert := range alerts {
+			if strings.EqualFold(alert.ID, id) {
+				return alert
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Bot) buildAlert(ctx context.Context, spec AlertSpec) (*models.UserAlert, error) {
+	if spec.ChatID == 0 {
+		return nil, fmt.Errorf("%w: chat_id is required", ErrInvalidAlert)
+	}
+	if !validAlertName(spec.Name) {
+		return nil, fmt.Errorf("%w: names may only contain letters, digits, '-' and '_' (max 32 characters)", ErrInvalidAlert)
+	}
+
+	alert := &models.UserAlert{
+		UserID:  spec.ChatID,
+		ChatID:  spec.ChatID,
+		Name:    spec.Name,
+		Enabled: spec.Enabled,
+	}
+
+	switch {
+	case spec.Rule != "" && spec.hasFilters():
+		return nil, fmt.Errorf("%w: use either a rule or filters, not both", ErrInvalidAlert)
+	case spec.Rule != "":
+		if _, err := rules.Parse(spec.Rule); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAlert, err)
+		}
+		alert.Rule = spec.Rule
+	case spec.hasFilters():
+		if err := spec.applyFilters(alert); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAlert, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: an alert needs a rule or at least one filter", ErrInvalidAlert)
+	}
+
+	alert.Delivery = spec.Delivery
+	alert.DigestAt = spec.DigestAt
+	alert.DigestOverview = spec.DigestOverview
+	if err := checkDelivery(alert); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAlert, err)
+	}
+
+	if (alert.Topic != "" || alert.DigestOverview) && !b.limits(alert.ChatID).AI {
+		return nil, fmt.Errorf("%w: topic alerts and digest overviews need the pro plan", ErrNotInPlan)
+	}
+	if err := b.embedTopic(ctx, alert); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func TestCreateAlertFromSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    AlertSpec
+		check   func(models.UserAlert) bool
+		wantErr error
+	}{
+		{"rule with daily delivery", AlertSpec{Rule: "bitcoin", Delivery: models.DeliveryDaily}, func(a models.UserAlert) bool {
+			return a.Rule == "bitcoin" && a.Delivery == models.DeliveryDaily && a.DigestAt == defaultDigestAt
+		}, nil},
+		{"keywords keep commas", AlertSpec{Keywords: []string{"1,000 BTC", " etf ", ""}}, func(a models.UserAlert) bool {
+			return reflect.DeepEqual(a.Keywords, []string{"1,000 BTC", "etf"})
+		}, nil},
+		{"lists are normalised", AlertSpec{Categories: []string{" Crypto"}, Sentiments: []string{"Negative"}}, func(a models.UserAlert) bool {
+			return reflect.DeepEqual(a.Categories, []string{"crypto"}) && reflect.DeepEqual(a.Sentiments, []string{"negative"})
+		}, nil},
+		{"rule and filters", AlertSpec{Rule: "bitcoin", Keywords: []string{"etf"}}, nil, ErrInvalidAlert},
+		{"neither", AlertSpec{Delivery: models.DeliveryHourly}, nil, ErrInvalidAlert},
+		{"bad rule", AlertSpec{Rule: "bitcoin AND"}, nil, ErrInvalidAlert},
+		{"bad delivery", AlertSpec{Rule: "bitcoin", Delivery: "weekly"}, nil, ErrInvalidAlert},
+		{"at without daily", AlertSpec{Rule: "bitcoin", DigestAt: "08:00"}, nil, ErrInvalidAlert},
+		{"bad sentiment", AlertSpec{Sentiments: []string{"angry"}}, nil, ErrInvalidAlert},
+		{"bad confidence", AlertSpec{MinConfidence: 1.5}, nil, ErrInvalidAlert},
+		{"overview needs pro", AlertSpec{Rule: "bitcoin", Delivery: models.DeliveryDaily, DigestOverview: true}, nil, ErrNotInPlan},
+	}
+
+	for i, tt := range tests {
+		bot, _ := newTestBot(t)
+		tt.spec.ChatID = int64(i + 1)
+		tt.spec.Name = "alert"
+		tt.spec.Enabled = true
+
+		alert, err := bot.CreateAlert(context.Background(), tt.spec)
+		switch {
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case !tt.check(alert):
+			t.Errorf("%s: unexpected alert %+v", tt.name, alert)
+		}
+	}
+}
+
+func TestUpdateAlertKeepsIdentity(t *testing.T) {
+	bot, _ := newTestBot(t)
+	created, err := bot.CreateAlert(context.Background(), AlertSpec{ChatID: 1, Name: "btc", Rule: "bitcoin", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := bot.UpdateAlert(context.Background(), created.ID, AlertSpec{Keywords: []string{"ether"}, Delivery: models.DeliveryHourly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != created.ID || updated.Name != "btc" || updated.Rule != "" || updated.Delivery != models.DeliveryHourly {
+		t.Errorf("unexpected update %+v", updated)
+	}
+	if _, err := bot.UpdateAlert(context.Background(), "missing", AlertSpec{Rule: "bitcoin"}); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("update of a missing alert: %v", err)
+	}
+}
+
+func TestRemovingAlertDropsPendingDigest(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(b *Bot, alert models.UserAlert)
+	}{
+		{"/alert remove", func(b *Bot, alert models.UserAlert) { b.handleAlertRemove(alert.ChatID, alert.Name) }},
+		{"DeleteAlert", func(b *Bot, alert models.UserAlert) { b.DeleteAlert(alert.ID) }},
+	}
+
+	for _, tt := range tests {
+		bot, _ := newTestBot(t)
+		alert, err := bot.CreateAlert(context.Background(), AlertSpec{ChatID: 1, Name: "btc", Rule: "bitcoin", Delivery: models.DeliveryHourly, Enabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bot.pendingDigests[alert.ID] = []string{"h1"}
+
+		tt.remove(bot, alert)
+		if _, ok := bot.pendingDigests[alert.ID]; ok {
+			t.Errorf("%s: the pending digest was kept", tt.name)
+		}
+		if len(bot.chatAlerts[1]) != 0 {
+			t.Errorf("%s: the alert was not removed", tt.name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxAskArticles     = 8
+	minAskSimilarity   = 0.3
+	maxQuestionLength  = 300
+	keywordAskFallback = 4
+	askTimeout         = 45 * time.Second
+)
+
+type QuestionAnswerer interface {
+	AnswerQuestion(ctx context.Context, question string, articles []models.CategorizedArticle) (models.Answer, error)
+}
+
+func (b *Bot) SetQuestionAnswerer(answerer QuestionAnswerer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.answerer = answerer
+}
+
+func (b *Bot) handleAsk(ctx context.Context, chatID int64, text string) {
+	b.mu.RLock()
+	answerer := b.answerer
+	b.mu.RUnlock()
+
+	if answerer == nil || b.articleCache() == nil {
+		b.sendMessage(chatID, "Questions are not available right now.")
+		return
+	}
+	if !b.limits(chatID).AI {
+		b.sendMessage(chatID, b.upgradeMessage(chatID, "Questions with /ask"))
+		return
+	}
+
+	_, question, _ := strings.Cut(text, " ")
+	question = strings.TrimSpace(question)
+	if question == "" {
+		b.sendMessage(chatID, "Usage: /ask why is SOL down today?")
+		return
+	}
+	if utf8.RuneCountInString(question) > maxQuestionLength {
+		b.sendMessage(chatID, fmt.Sprintf("Please keep questions under %d characters.", maxQuestionLength))
+		return
+	}
+
+	if !b.startAsking(chatID) {
+		b.sendMessage(chatID, "I'm still answering your last question. Please wait for it before asking another.")
+		return
+	}
+
+	// Retrieval and the model call take seconds, so they run off the update
+	// loop; each chat gets one question at a time.
+	go func() {
+		defer b.doneAsking(chatID)
+
+		ctx, cancel := context.WithTimeout(ctx, askTimeout)
+		defer cancel()
+		b.answerQuestion(ctx, answerer, chatID, question)
+	}()
+}
+
+func (b *Bot) answerQuestion(ctx context.Context, answerer QuestionAnswerer, chatID int64, question string) {
+	articles := b.retrieveArticles(ctx, question)
+	if len(articles) == 0 {
+		b.sendMessage(chatID, "I couldn't find any recent articles about that.")
+		return
+	}
+
+	b.enqueueChattable(chatID, tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+
+	answer, err := answerer.AnswerQuestion(ctx, question, articles)
+	if err != nil {
+		log.Printf("Failed to answer question in chat %d: %v", chatID, err)
+		b.sendMessage(chatID, "Failed to answer your question. Please try again later.")
+		return
+	}
+
+	b.sendRendered(chatID, renderAnswer(b.renderer, question, answer, articles))
+}
+
+// startAsking claims the chat's question slot, reporting false while an
+// earlier question is still being answered.
+func (b *Bot) startAsking(chatID int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.asking[chatID] {
+		return false
+	}
+	b.asking[chatID] = true
+	return true
+}
+
+func (b *Bot) doneAsking(chatID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.asking, chatID)
+}
+
+// retrieveArticles finds articles for a question by meaning when embeddings
+// are available, topping up with keyword matches so names and tickers the
+// embedding misses still count.
+func (b *Bot) retrieveArticles(ctx context.Context, question string) []models.CategorizedArticle {
+	b.mu.RLock()
+	embedder := b.embedder
+	vectors := b.vectors
+	articleCache := b.articles
+	b.mu.RUnlock()
+
+	var articles []models.CategorizedArticle
+	seen := make(map[string]bool)
+
+	if embedder != nil && vectors != nil {
+		embedding, err := embedder.Embed(ctx, question)
+		if err != nil {
+			log.Printf("Failed to embed question, using keyword retrieval: %v", err)
+		} else {
+			for _, match := range vectors.Search(embedding, maxAskArticles) {
+				if match.Similarity < minAskSimilarity {
+					break
+				}
+				if article, ok := articleCache.GetCategorizedArticle(match.Hash); ok {
+					articles = append(articles, article)
+					seen[article.Hash] = true
+				}
+			}
+		}
+	}
+
+	limit := maxAskArticles
+	if len(articles) > 0 {
+		limit = keywordAskFallback
+	}
+	for _, article := range articleCache.RankCategorized(question, limit) {
+		if len(articles) == maxAskArticles {
+			break
+		}
+		if !seen[article.Hash] {
+			articles = append(articles, article)
+			seen[article.Hash] = true
+		}
+	}
+
+	return articles
+}
+
+func renderAnswer(r renderer, question string, answer models.Answer, articles []models.CategorizedArticle) string {
+	var sources strings.Builder
+	if len(answer.Citations) > 0 {
+		sources.WriteString("\n\n" + r.bold("Sources"))
+		for _, i := range answer.Citations {
+			article := articles[i]
+			sources.WriteString("\n" + r.escape(fmt.Sprintf("[%d] ", i+1)) + r.link(article.Title, article.URL) +
+				r.escape(" - "+articleSource(article)))
+		}
+	}
+
+	header := r.escape("💬 ") + r.bold(question) + "\n\n"
+	budget := maxMessageLength - utf8.RuneCountInString(header) - utf8.RuneCountInString(sources.String())
+	return truncateRendered(header+escapeTruncated(r, answer.Text, budget)+sources.String(), maxMessageLength)
+}
@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/quota"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// blockingAnswerer answers once release is closed, or fails when the
+// request's context ends first.
+type blockingAnswerer struct {
+	release chan struct{}
+	calls   chan string
+}
+
+func (a *blockingAnswerer) AnswerQuestion(ctx context.Context, question string, articles []models.CategorizedArticle) (models.Answer, error) {
+	a.calls <- question
+	select {
+	case <-a.release:
+		return models.Answer{Text: "Inflows hit a record.", Citations: []int{0}}, nil
+	case <-ctx.Done():
+		return models.Answer{}, ctx.Err()
+	}
+}
+
+// queuedTexts returns the text of the messages waiting in a chat's queue.
+func queuedTexts(bot *Bot, chatID int64) []string {
+	bot.outbox.mu.Lock()
+	defer bot.outbox.mu.Unlock()
+
+	var texts []string
+	if queue, ok := bot.outbox.chats[chatID]; ok {
+		for _, message := range queue.messages {
+			if config, ok := message.message.(tgbotapi.MessageConfig); ok {
+				texts = append(texts, config.Text)
+			}
+		}
+	}
+	return texts
+}
+
+func TestAskRunsOneQuestionPerChatInBackground(t *testing.T) {
+	bot, _ := newTestBot(t)
+
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	article := testArticle()
+	article.Hash = "h1"
+	article.URL = "https://example.com/etf"
+	articles.AddCategorizedArticle(article)
+
+	answerer := &blockingAnswerer{release: make(chan struct{}), calls: make(chan string, 2)}
+	bot.SetDigestSources(articles, nil)
+	bot.SetQuestionAnswerer(answerer)
+	bot.quotas.SetTier(1, quota.TierPro)
+	bot.quotas.SetTier(2, quota.TierPro)
+
+	bot.handleAsk(context.Background(), 1, "/ask why are bitcoin ETF inflows up?")
+	if question := <-answerer.calls; question != "why are bitcoin ETF inflows up?" {
+		t.Errorf("question = %q", question)
+	}
+
+	bot.handleAsk(context.Background(), 1, "/ask and ethereum?")
+	texts := queuedTexts(bot, 1)
+	if len(texts) != 1 || !strings.Contains(texts[0], "still answering") {
+		t.Fatalf("second question in the same chat queued %q", texts)
+	}
+
+	bot.handleAsk(context.Background(), 2, "/ask bitcoin news?")
+	<-answerer.calls
+
+	close(answerer.release)
+	waitFor(t, time.Second, func() bool { return len(queuedTexts(bot, 1)) == 2 && len(queuedTexts(bot, 2)) == 1 })
+	if answer := queuedTexts(bot, 1)[1]; !strings.Contains(answer, "Inflows hit a record.") || !strings.Contains(answer, "https://example.com/etf") {
+		t.Errorf("answer = %q", answer)
+	}
+
+	waitFor(t, time.Second, func() bool { return bot.startAsking(1) })
+}
+
+func TestAskUsageErrors(t *testing.T) {
+	bot, _ := newTestBot(t)
+	articles := cache.New(time.Hour)
+	t.Cleanup(articles.Close)
+	bot.SetDigestSources(articles, nil)
+	bot.SetQuestionAnswerer(&blockingAnswerer{})
+	bot.quotas.SetTier(2, quota.TierPro)
+
+	tests := []struct {
+		chatID int64
+		text   string
+		want   string
+	}{
+		{1, "/ask why?", "/ask"},
+		{2, "/ask", "Usage"},
+		{2, "/ask " + strings.Repeat("a", maxQuestionLength+1), "under 300 characters"},
+		{2, "/ask anything about zebras?", "couldn't find"},
+	}
+
+	for i, tt := range tests {
+		bot.handleAsk(context.Background(), tt.chatID, tt.text)
+		waitFor(t, time.Second, func() bool { return len(queuedTexts(bot, tt.chatID)) > 0 })
+
+		texts := queuedTexts(bot, tt.chatID)
+		if !strings.Contains(texts[len(texts)-1], tt.want) {
+			t.Errorf("case %d: reply %q, want it to mention %q", i, texts[len(texts)-1], tt.want)
+		}
+		bot.outbox.mu.Lock()
+		delete(bot.outbox.chats, tt.chatID)
+		bot.outbox.mu.Unlock()
+	}
+}
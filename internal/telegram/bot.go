@@ -6,29 +6,95 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/feedback"
 	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/quota"
+	"github.com/ObiAU/hfnewsaggregator/internal/vectorstore"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+const maxDeliveries = 5000
+
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
 type Bot struct {
-	api        *tgbotapi.BotAPI
-	webhookURL string
-	userAlerts map[int64]*models.UserAlert
-	mu         sync.RWMutex
+	api               *tgbotapi.BotAPI
+	outbox            *outbox
+	renderer          renderer
+	webhookURL        string
+	chatAlerts        map[int64][]*models.UserAlert
+	nextAlertID       int64
+	deliveries        []models.Delivery
+	nextDeliveryID    int64
+	pendingDigests    map[string][]string
+	chatSettings      map[int64]*models.UserSettings
+	heldAlerts        map[int64][]heldAlert
+	wizards           map[int64]*alertWizard
+	asking            map[int64]bool
+	feedback          *feedback.Store
+	adminChecks       map[adminKey]adminCheck
+	admins            map[int64]bool
+	adminBackend      AdminBackend
+	quotas            *quota.Store
+	mailingList       MailingList
+	articles          *cache.Cache
+	summarizer        DigestSummarizer
+	answerer          QuestionAnswerer
+	embedder          Embedder
+	vectors           *vectorstore.Store
+	semanticThreshold float64
+	mu                sync.RWMutex
 }
 
-func NewBot(token, webhookURL string) *Bot {
+func NewBot(token, webhookURL, parseMode string) *Bot {
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		log.Fatalf("Failed to create telegram bot: %v", err)
 	}
+	return NewBotWithAPI(bot, webhookURL, parseMode)
+}
+
+// NewBotWithAPI wraps an already configured Bot API client, such as one
+// pointed at a local Bot API server.
+func NewBotWithAPI(bot *tgbotapi.BotAPI, webhookURL, parseMode string) *Bot {
+	r := newRenderer(parseMode)
+	if r == nil {
+		log.Fatalf("Invalid TELEGRAM_PARSE_MODE %q: use HTML or MarkdownV2", parseMode)
+	}
 
-	return &Bot{
-		api:        bot,
-		webhookURL: webhookURL,
-		userAlerts: make(map[int64]*models.UserAlert),
+	b := &Bot{
+		api:            bot,
+		renderer:       r,
+		outbox:         newOutbox(bot),
+		webhookURL:     webhookURL,
+		chatAlerts:     make(map[int64][]*models.UserAlert),
+		pendingDigests: make(map[string][]string),
+		chatSettings:   make(map[int64]*models.UserSettings),
+		heldAlerts:     make(map[int64][]heldAlert),
+		wizards:        make(map[int64]*alertWizard),
+		asking:         make(map[int64]bool),
+		feedback:       feedback.NewStore(),
+		adminChecks:    make(map[adminKey]adminCheck),
+		quotas:         quota.NewStore(),
 	}
+	b.outbox.onFail = b.handleSendFailure
+
+	return b
+}
+
+func (b *Bot) SetSemanticSearch(embedder Embedder, vectors *vectorstore.Store, threshold float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.embedder = embedder
+	b.vectors = vectors
+	b.semanticThreshold = threshold
 }
 
 func (b *Bot) Start(ctx context.Context) error {
@@ -59,25 +125,84 @@ func (b *Bot) Start(ctx context.Context) error {
 		}
 	}()
 
+	b.outbox.start(ctx)
+	go b.runDigests(ctx)
+
 	return nil
 }
 
 func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
-	if update.Message == nil {
+	switch {
+	case update.CallbackQuery != nil:
+		b.handleCallback(ctx, update.CallbackQuery)
+		return
+	case update.MyChatMember != nil:
+		b.handleMyChatMember(update.MyChatMember)
 		return
 	}
 
-	userID := update.Message.From.ID
-	chatID := update.Message.Chat.ID
-	text := update.Message.Text
+	// Channel posts carry commands from channel admins and have no sender.
+	message := update.Message
+	if message == nil {
+		message = update.ChannelPost
+	}
+	if message == nil || message.Chat == nil {
+		return
+	}
+	if message.MigrateToChatID != 0 {
+		b.migrateChat(message.Chat.ID, message.MigrateToChatID)
+		return
+	}
+
+	chatID := message.Chat.ID
+	userID := chatID
+	if message.From != nil {
+		userID = message.From.ID
+	}
+
+	text, forUs := b.commandText(message.Text)
+	if !forUs {
+		return
+	}
+	if !strings.HasPrefix(text, "/") {
+		if !b.handleWizardReply(userID, chatID, text) && message.Chat.IsPrivate() {
+			b.handleUnknownCommand(chatID)
+		}
+		return
+	}
+
+	b.enableChat(chatID)
+
+	if isConfigCommand(text) && !b.canConfigure(message, userID) {
+		b.sendMessage(chatID, "Only chat admins can change this chat's alerts and settings.")
+		return
+	}
 
 	switch {
 	case strings.HasPrefix(text, "/start"):
 		b.handleStart(chatID)
 	case strings.HasPrefix(text, "/alert"):
 		b.handleAlertCommand(ctx, userID, chatID, text)
+	case strings.HasPrefix(text, "/timezone"):
+		b.handleTimezone(chatID, text)
+	case strings.HasPrefix(text, "/quiet"):
+		b.handleQuiet(chatID, text)
+	case strings.HasPrefix(text, "/format"):
+		b.handleFormat(chatID, text)
+	case strings.HasPrefix(text, "/latest"):
+		b.handleLatest(chatID, text)
+	case strings.HasPrefix(text, "/search"):
+		b.handleSearch(chatID, text)
+	case strings.HasPrefix(text, "/article"):
+		b.handleArticle(chatID, text)
+	case strings.HasPrefix(text, "/ask"):
+		b.handleAsk(ctx, chatID, text)
+	case strings.HasPrefix(text, "/admin"):
+		b.handleAdmin(userID, chatID, text)
 	case strings.HasPrefix(text, "/list"):
 		b.handleListAlerts(chatID)
+	case strings.HasPrefix(text, "/usage"):
+		b.handleUsage(chatID)
 	case strings.HasPrefix(text, "/help"):
 		b.handleHelp(chatID)
 	default:
@@ -85,88 +210,46 @@ func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 	}
 }
 
+func (b *Bot) handleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	switch {
+	case query.Message == nil:
+		b.answerCallback(query.ID, "")
+	case strings.HasPrefix(query.Data, wizardPrefix):
+		b.handleWizardCallback(ctx, query)
+	case strings.HasPrefix(query.Data, feedbackPrefix):
+		b.handleFeedbackCallback(query)
+	default:
+		b.answerCallback(query.ID, "")
+	}
+}
+
+func (b *Bot) answerCallback(id, text string) {
+	if _, err := b.api.Request(tgbotapi.NewCallback(id, text)); err != nil {
+		log.Printf("Failed to answer callback query: %v", err)
+	}
+}
+
 func (b *Bot) handleStart(chatID int64) {
 	msg := tgbotapi.NewMessage(chatID, `Welcome to HF News Aggregator! 📰
 
 I'll send you personalized news alerts based on your preferences.
 
 Commands:
-/alert set category=politics keywords=bitcoin,crypto
-/alert set category=technology tags=ai,blockchain
+/alert new - Set up an alert step by step with buttons
+/alert add crypto category=cryptocurrency keywords=bitcoin,ethereum
+/alert add ai category=technology tags=ai,blockchain
 /list - View your current alerts
+/latest crypto - What's new in a category
+/search ethereum etf - Search recent articles
 /help - Show this help message
 
 Example alert formats:
-• /alert set category=cryptocurrency
-• /alert set keywords=bitcoin,ethereum,defi
-• /alert set category=politics keywords=election,policy
-• /alert set tags=ai,machine learning category=technology`)
+• /alert add btc category=cryptocurrency
+• /alert add defi keywords=bitcoin,ethereum,defi
+• /alert add elections category=politics keywords=election,policy
+• /alert add ml tags="ai,machine learning" category=technology`)
 
-	b.api.Send(msg)
-}
-
-func (b *Bot) handleAlertCommand(ctx context.Context, userID, chatID int64, text string) {
-	parts := strings.Fields(text)
-	if len(parts) < 3 {
-		b.sendMessage(chatID, "Invalid alert format. Use: /alert set category=politics keywords=bitcoin,crypto")
-		return
-	}
-
-	alert := &models.UserAlert{
-		UserID:  userID,
-		ChatID:  chatID,
-		Enabled: true,
-	}
-
-	for _, part := range parts[2:] {
-		if strings.Contains(part, "=") {
-			kv := strings.SplitN(part, "=", 2)
-			key, value := kv[0], kv[1]
-
-			switch key {
-			case "category":
-				alert.Categories = append(alert.Categories, value)
-			case "keywords":
-				keywords := strings.Split(value, ",")
-				alert.Keywords = append(alert.Keywords, keywords...)
-			case "tags":
-				tags := strings.Split(value, ",")
-				alert.Tags = append(alert.Tags, tags...)
-			}
-		}
-	}
-
-	b.mu.Lock()
-	b.userAlerts[userID] = alert
-	b.mu.Unlock()
-
-	response := fmt.Sprintf("Alert configured! 🎯\n\nCategories: %v\nKeywords: %v\nTags: %v",
-		alert.Categories, alert.Keywords, alert.Tags)
-	b.sendMessage(chatID, response)
-}
-
-func (b *Bot) handleListAlerts(chatID int64) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-
-	var userID int64
-	for uid, alert := range b.userAlerts {
-		if alert.ChatID == chatID {
-			userID = uid
-			break
-		}
-	}
-
-	alert, exists := b.userAlerts[userID]
-	if !exists {
-		b.sendMessage(chatID, "No alerts configured. Use /alert set to create one.")
-		return
-	}
-
-	response := fmt.Sprintf("Your current alerts: 📋\n\nCategories: %v\nKeywords: %v\nTags: %v\nStatus: %s",
-		alert.Categories, alert.Keywords, alert.Tags,
-		map[bool]string{true: "Enabled", false: "Disabled"}[alert.Enabled])
-	b.sendMessage(chatID, response)
+	b.enqueueChattable(chatID, msg)
 }
 
 func (b *Bot) handleHelp(chatID int64) {
@@ -174,20 +257,59 @@ func (b *Bot) handleHelp(chatID int64) {
 
 Commands:
 /start - Welcome message and setup
-/alert set [options] - Configure news alerts
-/list - View your current alerts
+/alert new [name] - Set up an alert step by step with buttons
+/alert add [name] [options] - Add a named news alert
+/alert set [options] - Configure your default alert
+/alert rule [name] [expression] - Add or replace an alert with a boolean rule
+/alert remove [name] - Delete an alert
+/alert pause [name] / /alert resume [name] - Stop or restart an alert
+/list - View your alerts with their IDs
+/timezone [Area/City] - Show or set your timezone
+/quiet 23:00-07:00 - Hold non-urgent alerts overnight (/quiet off to disable)
+/quiet urgent=0.9 - Urgency that still breaks through quiet hours
+/format compact|full|headline - Choose how alerts look
+/format custom [template] - Your own layout, e.g. {title} ({source}) {url}
+/latest [category] [n] [window] - Recent articles, e.g. /latest crypto 10 1h
+/search [query] - Search recently cached articles
+/article [id] - Full summary and details for an article
+/ask [question] - Ask about recent news, e.g. /ask why is SOL down today?
+/usage - Your plan, today's alert count and limits
 /help - Show this help
 
 Alert Configuration Options:
 • category=politics - Filter by news category
 • keywords=bitcoin,crypto - Filter by keywords
 • tags=ai,blockchain - Filter by tags
+• topic="ethereum exchange-traded fund" - Match articles about a topic by meaning
+• threshold=0.6 - Topic similarity required (0-1)
+• language=en,es - Only articles in (or translated into) these languages
+• min_confidence=0.8 - Only articles categorized with at least this confidence
+• sentiment=negative - Only articles with these sentiments
+• sources=treenews,cryptopanic - Only these feeds or outlets
+• exclude_sources=newsapi - Never these feeds or outlets
+• max_age=30m - Skip articles published longer ago than this
+• delivery=instant|hourly|daily - Send immediately or collect into a digest
+• at=08:00 - Time of day for daily digests
+• overview=true - Start digests with an AI-written overview
 
 Examples:
-/alert set category=cryptocurrency
-/alert set keywords=bitcoin,ethereum,defi
-/alert set category=politics keywords=election,policy
-/alert set tags=ai,machine learning category=technology
+/alert add btc category=cryptocurrency
+/alert add defi keywords=bitcoin,ethereum,defi
+/alert add elections category=politics keywords=election,policy
+/alert add ml tags="ai,machine learning" category=technology
+/alert add rates topic="central bank interest rate decisions"
+/alert add selloff category=cryptocurrency sentiment=negative min_confidence=0.8 sources=treenews,cryptopanic
+/alert add morning category=finance,business delivery=daily at=07:30 overview=true
+
+Options in one alert must all match; comma-separated values match any.
+In groups and channels alerts belong to the chat and only admins can change them.
+Alerts can be referenced by name or by the ID shown in /list.
+
+Alert Rules:
+Combine terms with AND, OR, NOT (or -term) and parentheses. Adjacent terms are ANDed.
+Fields: category:, tag:, source:, sentiment:, lang:, title: - bare words and "quoted phrases" search the text.
+/alert rule crypto category:crypto AND (bitcoin OR btc) AND NOT "price prediction"
+/alert rule sec sentiment:negative (tag:sec OR "enforcement action")
 
 Categories: politics, technology, cryptocurrency, finance, sports, entertainment, health, science, world, business`
 
@@ -198,78 +320,195 @@ func (b *Bot) handleUnknownCommand(chatID int64) {
 	b.sendMessage(chatID, "Unknown command. Use /help for available commands.")
 }
 
+// SendAlert delivers article once to every user with a matching instant
+// alert with feedback buttons attached, records which alert triggered each
+// message, and queues the article
+// for any matching digest alerts. Non-urgent alerts during a user's quiet
+// hours are held and flushed as a digest afterwards.
 func (b *Bot) SendAlert(ctx context.Context, article models.CategorizedArticle) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
+	now := time.Now()
 
-	for _, alert := range b.userAlerts {
-		if !alert.Enabled {
+	b.mu.Lock()
+	var matches []*models.UserAlert
+	templates := make(map[int64]string)
+	for chatID, alerts := range b.chatAlerts {
+		if b.settingsLocked(chatID).Disabled {
 			continue
 		}
 
-		if b.matchesAlert(article, alert) {
-			message := b.formatAlertMessage(article)
-			b.sendMessage(alert.ChatID, message)
+		sentInstant := false
+		for _, alert := range alerts {
+			if !alert.Enabled || !b.matchesAlert(article, alert) {
+				continue
+			}
+
+			if alert.Delivery != "" && alert.Delivery != models.DeliveryInstant {
+				b.queueDigestLocked(alert, article.Hash)
+				continue
+			}
+
+			if sentInstant {
+				continue
+			}
+			sentInstant = true
+
+			if b.shouldHoldLocked(chatID, article, now) {
+				b.holdAlertLocked(alert, article.Hash)
+				continue
+			}
+			matches = append(matches, alert)
+			templates[chatID] = b.settingsLocked(chatID).AlertTemplate
 		}
 	}
-}
+	b.mu.Unlock()
 
-func (b *Bot) matchesAlert(article models.CategorizedArticle, alert *models.UserAlert) bool {
-	for _, category := range alert.Categories {
-		if strings.EqualFold(article.Category, category) {
-			return true
+	for _, alert := range matches {
+		if b.reserveAlerts(alert.ChatID, 1, now) == 0 {
+			continue
 		}
-	}
 
-	for _, keyword := range alert.Keywords {
-		content := strings.ToLower(article.Title + " " + article.Content)
-		if strings.Contains(content, strings.ToLower(keyword)) {
-			return true
+		delivery := models.Delivery{
+			UserID:      alert.UserID,
+			ChatID:      alert.ChatID,
+			AlertID:     alert.ID,
+			AlertName:   alert.Name,
+			ArticleHash: article.Hash,
+			Category:    article.Category,
+			Source:      article.Source,
 		}
-	}
 
-	for _, tag := range alert.Tags {
-		for _, articleTag := range article.Tags {
-			if strings.EqualFold(articleTag, tag) {
-				return true
-			}
+		msg := tgbotapi.NewMessage(alert.ChatID, renderAlert(b.renderer, templates[alert.ChatID], article, alert))
+		msg.ParseMode = b.renderer.parseMode()
+		msg.DisableWebPagePreview = true
+		msg.ReplyMarkup = feedbackKeyboard("")
+
+		charge, release := b.chargeOnDelivery(alert.ChatID, 1)
+		err := b.outbox.enqueue(&outboundMessage{
+			chatID:  alert.ChatID,
+			message: msg,
+			onSent: func(sent tgbotapi.Message) {
+				charge(sent)
+				delivery.MessageID = sent.MessageID
+				delivery.SentAt = time.Now()
+				b.recordDelivery(delivery)
+			},
+			onDropped: release,
+		})
+		if err != nil {
+			release(err)
+			log.Printf("Failed to queue alert %s for user %d: %v", alert.ID, alert.UserID, err)
 		}
 	}
+}
 
-	return false
+func (b *Bot) Name() string {
+	return "telegram"
 }
 
-func (b *Bot) formatAlertMessage(article models.CategorizedArticle) string {
-	return fmt.Sprintf(`🚨 News Alert
+// Notify lets the bot act as a notifier; users' alerts are its
+// subscription rules.
+func (b *Bot) Notify(ctx context.Context, article models.CategorizedArticle) error {
+	b.SendAlert(ctx, article)
+	return nil
+}
 
-📰 %s
+func (b *Bot) recordDelivery(delivery models.Delivery) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-📂 Category: %s
-🏷️ Tags: %s
-😊 Sentiment: %s
-📊 Confidence: %.1f%%
+	b.nextDeliveryID++
+	delivery.ID = fmt.Sprintf("D%d", b.nextDeliveryID)
 
-📝 Summary: %s
+	b.deliveries = append(b.deliveries, delivery)
+	if len(b.deliveries) > maxDeliveries {
+		b.deliveries = b.deliveries[len(b.deliveries)-maxDeliveries:]
+	}
+}
 
-🔗 Read more: %s
+func (b *Bot) Deliveries(userID int64) []models.Delivery {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 
-Source: %s`,
-		article.Title,
-		article.Category,
-		strings.Join(article.Tags, ", "),
-		article.Sentiment,
-		article.Confidence*100,
-		article.Summary,
-		article.URL,
-		article.Source)
+	var deliveries []models.Delivery
+	for _, delivery := range b.deliveries {
+		if delivery.UserID == userID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries
 }
 
-func (b *Bot) sendMessage(chatID int64, text string) {
+// splitArgs splits a command into whitespace-separated fields, keeping
+// double-quoted sections together so that key="multi word value" survives.
+func splitArgs(text string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	hasArg := false
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+
+	if hasArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
+// enqueue queues a message for delivery; onSent, if set, runs once Telegram
+// has accepted it.
+func (b *Bot) enqueue(chatID int64, text, parseMode string, onSent func(tgbotapi.Message)) error {
 	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ParseMode = "HTML"
+	msg.ParseMode = parseMode
 	msg.DisableWebPagePreview = true
 
-	_, err := b.api.Send(msg)
+	return b.outbox.enqueue(&outboundMessage{
+		chatID:  chatID,
+		message: msg,
+		onSent:  onSent,
+	})
+}
+
+func (b *Bot) enqueueChattable(chatID int64, msg tgbotapi.Chattable) {
+	err := b.outbox.enqueue(&outboundMessage{
+		chatID:  chatID,
+		message: msg,
+	})
+	if err != nil {
+		log.Printf("Failed to send telegram message: %v", err)
+	}
+}
+
+// QueueLength reports how many messages are waiting in the send queue.
+func (b *Bot) QueueLength() int {
+	return b.outbox.pending()
+}
+
+func (b *Bot) sendMessage(chatID int64, text string) {
+	err := b.enqueue(chatID, text, tgbotapi.ModeHTML, nil)
+	if err != nil {
+		log.Printf("Failed to send telegram message: %v", err)
+	}
+}
+
+// sendRendered sends article content built with the bot's renderer.
+func (b *Bot) sendRendered(chatID int64, text string) {
+	err := b.enqueue(chatID, text, b.renderer.parseMode(), nil)
 	if err != nil {
 		log.Printf("Failed to send telegram message: %v", err)
 	}
@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeTelegram stands in for the Bot API and records every call.
+type fakeTelegram struct {
+	mu    sync.Mutex
+	calls []fakeCall
+}
+
+type fakeCall struct {
+	method string
+	chatID int64
+	text   string
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	chatID, _ := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{method: method, chatID: chatID, text: r.Form.Get("text")})
+	messageID := len(f.calls)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":     true,
+		"result": map[string]interface{}{"message_id": messageID, "chat": map[string]int64{"id": chatID}},
+	})
+}
+
+// messages returns the text of every sendMessage call to chatID.
+func (f *fakeTelegram) messages(chatID int64) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var texts []string
+	for _, call := range f.calls {
+		if call.method == "sendMessage" && call.chatID == chatID {
+			texts = append(texts, call.text)
+		}
+	}
+	return texts
+}
+
+func newTestBot(t *testing.T) (*Bot, *fakeTelegram) {
+	t.Helper()
+
+	fake := &fakeTelegram{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	api := &tgbotapi.BotAPI{Token: "test", Client: server.Client(), Buffer: 100}
+	api.SetAPIEndpoint(server.URL + "/bot%s/%s")
+	return NewBotWithAPI(api, "", "HTML"), fake
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"/alert set btc keywords=bitcoin", []string{"/alert", "set", "btc", "keywords=bitcoin"}},
+		{`/alert set fed keywords="rate cut,fomc"  sources=reuters`, []string{"/alert", "set", "fed", "keywords=rate cut,fomc", "sources=reuters"}},
+		{`say ""`, []string{"say", ""}},
+		{"   ", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitArgs(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package telegram
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const adminCheckTTL = 5 * time.Minute
+
+type adminKey struct {
+	chatID int64
+	userID int64
+}
+
+type adminCheck struct {
+	isAdmin   bool
+	checkedAt time.Time
+}
+
+// commandText strips a "@botname" suffix from the command so group members
+// can address the bot explicitly. ok is false for commands meant for
+// another bot.
+func (b *Bot) commandText(text string) (string, bool) {
+	command, rest, _ := strings.Cut(text, " ")
+	name, target, addressed := strings.Cut(command, "@")
+	if !addressed {
+		return text, true
+	}
+	if !strings.EqualFold(target, b.api.Self.UserName) {
+		return text, false
+	}
+	if rest == "" {
+		return name, true
+	}
+	return name + " " + rest, true
+}
+
+// isConfigCommand reports whether text changes a chat's subscriptions or
+// settings, which in groups only admins may do.
+func isConfigCommand(text string) bool {
+	parts := strings.Fields(text)
+	if len(parts) == 0 {
+		return false
+	}
+
+	switch parts[0] {
+	case "/alert":
+		return true
+	case "/timezone", "/quiet", "/format":
+		return len(parts) > 1
+	default:
+		return false
+	}
+}
+
+// canConfigure reports whether a user may change the chat's alerts. Anyone
+// can in a private chat, and only admins can post in a channel; in groups
+// the user must be an administrator, which is cached briefly.
+func (b *Bot) canConfigure(message *tgbotapi.Message, userID int64) bool {
+	chat := message.Chat
+	if chat.IsPrivate() || chat.IsChannel() {
+		return true
+	}
+	// Anonymous group admins post as the group itself.
+	if message.SenderChat != nil && message.SenderChat.ID == chat.ID {
+		return true
+	}
+
+	key := adminKey{chatID: chat.ID, userID: userID}
+	b.mu.RLock()
+	check, ok := b.adminChecks[key]
+	b.mu.RUnlock()
+	if ok && time.Since(check.checkedAt) < adminCheckTTL {
+		return check.isAdmin
+	}
+
+	member, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chat.ID, UserID: userID},
+	})
+	if err != nil {
+		log.Printf("Failed to check admin status of user %d in chat %d: %v", userID, chat.ID, err)
+		return false
+	}
+
+	isAdmin := member.IsAdministrator() || member.IsCreator()
+	b.mu.Lock()
+	b.adminChecks[key] = adminCheck{isAdmin: isAdmin, checkedAt: time.Now()}
+	b.mu.Unlock()
+
+	return isAdmin
+}
+
+// handleMyChatMember follows the bot being added to or removed from a chat.
+func (b *Bot) handleMyChatMember(update *tgbotapi.ChatMemberUpdated) {
+	status := update.NewChatMember
+	switch {
+	case status.HasLeft() || status.WasKicked():
+		b.disableChat(update.Chat.ID, "bot removed from chat")
+	case status.IsAdministrator() || status.IsCreator() || status.Status == "member":
+		b.enableChat(update.Chat.ID)
+	}
+}
+
+// handleSendFailure is called by the outbox when a message is dropped. A
+// 403 means the bot was blocked, kicked or lost the right to post, so the
+// chat's subscription is disabled rather than failing on every article.
+func (b *Bot) handleSendFailure(chatID int64, err error) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return
+	}
+
+	switch {
+	case apiErr.MigrateToChatID != 0:
+		b.migrateChat(chatID, apiErr.MigrateToChatID)
+	case apiErr.Code == 403:
+		b.disableChat(chatID, apiErr.Message)
+	}
+}
+
+func (b *Bot) disableChat(chatID int64, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.chatAlerts[chatID]; !ok {
+		return
+	}
+
+	settings := b.ensureSettingsLocked(chatID)
+	if settings.Disabled {
+		return
+	}
+	settings.Disabled = true
+	settings.DisabledReason = reason
+
+	delete(b.heldAlerts, chatID)
+	for _, alert := range b.chatAlerts[chatID] {
+		delete(b.pendingDigests, alert.ID)
+	}
+
+	log.Printf("Disabled alerts for chat %d: %s", chatID, reason)
+}
+
+// enableChat re-enables a chat disabled after a delivery failure, e.g. once
+// the bot is added back or the user unblocks it and sends a command.
+func (b *Bot) enableChat(chatID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	settings, ok := b.chatSettings[chatID]
+	if !ok || !settings.Disabled {
+		return
+	}
+	settings.Disabled = false
+	settings.DisabledReason = ""
+
+	log.Printf("Re-enabled alerts for chat %d", chatID)
+}
+
+// migrateChat moves a group's subscriptions when Telegram upgrades it to a
+// supergroup with a new ID.
+func (b *Bot) migrateChat(oldID, newID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if alerts, ok := b.chatAlerts[oldID]; ok {
+		for _, alert := range alerts {
+			alert.ChatID = newID
+		}
+		b.chatAlerts[newID] = append(b.chatAlerts[newID], alerts...)
+		delete(b.chatAlerts, oldID)
+	}
+	if settings, ok := b.chatSettings[oldID]; ok {
+		settings.ChatID = newID
+		b.chatSettings[newID] = settings
+		delete(b.chatSettings, oldID)
+	}
+	if held, ok := b.heldAlerts[oldID]; ok {
+		b.heldAlerts[newID] = append(b.heldAlerts[newID], held...)
+		delete(b.heldAlerts, oldID)
+	}
+
+	log.Printf("Migrated alerts from chat %d to %d", oldID, newID)
+}
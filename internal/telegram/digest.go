@@ -0,0 +1,246 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/schedule"
+)
+
+const (
+	digestCheckInterval    = 1 * time.Minute
+	maxDigestItemsCategory = 15
+	defaultDigestAt        = "08:00"
+)
+
+type DigestSummarizer interface {
+	SummarizeDigest(ctx context.Context, articles []models.CategorizedArticle) (string, error)
+}
+
+// dueDigest is a digest ready to send. rateLimit marks alerts held during
+// quiet hours, which count against the daily alert limit; scheduled digests
+// do not. reserved is how many of today's alerts were set aside for it.
+type dueDigest struct {
+	alert     models.UserAlert
+	hashes    []string
+	since     time.Time
+	title     string
+	icon      string
+	location  *time.Location
+	rateLimit bool
+	reserved  int
+}
+
+func (b *Bot) SetDigestSources(articles *cache.Cache, summarizer DigestSummarizer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.articles = articles
+	b.summarizer = summarizer
+}
+
+// queueDigestLocked holds an article for a digest alert; duplicates are ignored.
+// Callers must hold b.mu.
+func (b *Bot) queueDigestLocked(alert *models.UserAlert, hash string) {
+	for _, queued := range b.pendingDigests[alert.ID] {
+		if queued == hash {
+			return
+		}
+	}
+	b.pendingDigests[alert.ID] = append(b.pendingDigests[alert.ID], hash)
+}
+
+func (b *Bot) runDigests(ctx context.Context) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, digest := range b.collectDueDigests(now) {
+				if digest.rateLimit {
+					b.reserveHeld(&digest, now)
+				}
+				b.sendDigest(ctx, digest)
+			}
+		}
+	}
+}
+
+func (b *Bot) collectDueDigests(now time.Time) []dueDigest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	due := b.collectHeldLocked(now)
+	for chatID, alerts := range b.chatAlerts {
+		settings := b.settingsLocked(chatID)
+		if settings.Disabled {
+			continue
+		}
+		// Digests that come due during quiet hours stay pending and go out
+		// on the first check after the window ends.
+		if inQuietHours(settings, now) {
+			continue
+		}
+
+		loc := userLocation(settings)
+		for _, alert := range alerts {
+			if !alert.Enabled || !digestDue(alert, now, loc) {
+				continue
+			}
+
+			hashes := b.pendingDigests[alert.ID]
+			delete(b.pendingDigests, alert.ID)
+
+			since := alert.LastDigestAt
+			alert.LastDigestAt = now
+
+			if len(hashes) > 0 {
+				due = append(due, dueDigest{
+					alert:    *alert,
+					hashes:   hashes,
+					since:    since,
+					title:    alert.Name + " digest",
+					icon:     "🗞️",
+					location: loc,
+				})
+			}
+		}
+	}
+
+	return due
+}
+
+func digestDue(alert *models.UserAlert, now time.Time, loc *time.Location) bool {
+	switch alert.Delivery {
+	case models.DeliveryHourly:
+		return now.Sub(alert.LastDigestAt) >= time.Hour
+	case models.DeliveryDaily:
+		scheduled, err := schedule.LastOccurrence(alert.DigestAt, now.In(loc))
+		if err != nil {
+			return false
+		}
+		return alert.LastDigestAt.Before(scheduled)
+	default:
+		return false
+	}
+}
+
+func (b *Bot) sendDigest(ctx context.Context, digest dueDigest) {
+	b.mu.RLock()
+	articleCache := b.articles
+	summarizer := b.summarizer
+	b.mu.RUnlock()
+
+	var articles []models.CategorizedArticle
+	for _, hash := range digest.hashes {
+		if articleCache == nil {
+			break
+		}
+		if article, ok := articleCache.GetCategorizedArticle(hash); ok {
+			articles = append(articles, article)
+		}
+	}
+	if unused := digest.reserved - len(articles); unused > 0 {
+		b.quotaStore().Release(digest.alert.ChatID, unused)
+		digest.reserved -= unused
+	}
+	if len(articles) == 0 {
+		return
+	}
+
+	alert := digest.alert
+	r := b.renderer
+	info := fmt.Sprintf(" - %d articles", len(articles))
+	if !digest.since.IsZero() {
+		info += " since " + digest.since.In(digest.location).Format("Jan 2 15:04")
+	}
+	header := r.escape(digest.icon+" ") + r.bold(digest.title) + r.escape(info)
+
+	if alert.DigestOverview && summarizer != nil && b.limits(alert.ChatID).AI {
+		overview, err := summarizer.SummarizeDigest(ctx, articles)
+		if err != nil {
+			log.Printf("Failed to summarize digest for user %d: %v", alert.UserID, err)
+		} else if overview != "" {
+			budget := maxMessageLength - utf8.RuneCountInString(header) - 2
+			header += "\n\n" + escapeTruncated(r, overview, budget)
+		}
+	}
+
+	if digest.reserved > 0 {
+		b.sendCharged(alert.ChatID, header, digest.reserved)
+	} else {
+		b.sendRendered(alert.ChatID, header)
+	}
+
+	for _, message := range formatDigestByCategory(r, articles) {
+		b.sendRendered(alert.ChatID, message)
+	}
+}
+
+// formatDigestByCategory renders one message per category, most active
+// category first. Items that would push a message past Telegram's limit are
+// counted in the "more" line instead.
+func formatDigestByCategory(r renderer, articles []models.CategorizedArticle) []string {
+	byCategory := make(map[string][]models.CategorizedArticle)
+	for _, article := range articles {
+		category := article.Category
+		if category == "" {
+			category = "other"
+		}
+		byCategory[category] = append(byCategory[category], article)
+	}
+
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if len(byCategory[categories[i]]) != len(byCategory[categories[j]]) {
+			return len(byCategory[categories[i]]) > len(byCategory[categories[j]])
+		}
+		return categories[i] < categories[j]
+	})
+
+	messages := make([]string, 0, len(categories))
+	for _, category := range categories {
+		items := byCategory[category]
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Confidence > items[j].Confidence
+		})
+
+		var sb strings.Builder
+		sb.WriteString(r.escape("📂 ") + r.bold(capitalize(category)) + r.escape(fmt.Sprintf(" (%d)", len(items))) + "\n")
+		length := utf8.RuneCountInString(sb.String())
+		for i, article := range items {
+			line := "\n" + r.escape("• ") + r.link(article.Title, article.URL) + r.escape(" - "+article.Source)
+			more := r.escape(fmt.Sprintf("\n…and %d more", len(items)-i))
+			n := utf8.RuneCountInString(line)
+			if i == maxDigestItemsCategory || length+n+utf8.RuneCountInString(more) > maxMessageLength {
+				sb.WriteString(more)
+				break
+			}
+			sb.WriteString(line)
+			length += n
+		}
+		messages = append(messages, sb.String())
+	}
+
+	return messages
+}
+
+func capitalize(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
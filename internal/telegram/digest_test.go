@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func TestDigestDue(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		alert models.UserAlert
+		want  bool
+	}{
+		{"hourly, never sent", models.UserAlert{Delivery: models.DeliveryHourly}, true},
+		{"hourly, sent 59m ago", models.UserAlert{Delivery: models.DeliveryHourly, LastDigestAt: now.Add(-59 * time.Minute)}, false},
+		{"hourly, sent 1h ago", models.UserAlert{Delivery: models.DeliveryHourly, LastDigestAt: now.Add(-time.Hour)}, true},
+		{"daily, sent yesterday", models.UserAlert{Delivery: models.DeliveryDaily, DigestAt: "08:00", LastDigestAt: now.Add(-24 * time.Hour)}, true},
+		{"daily, already sent today", models.UserAlert{Delivery: models.DeliveryDaily, DigestAt: "08:00", LastDigestAt: now.Add(-30 * time.Minute)}, false},
+		{"daily, not yet time", models.UserAlert{Delivery: models.DeliveryDaily, DigestAt: "10:00", LastDigestAt: now.Add(-12 * time.Hour)}, false},
+		{"daily, bad clock", models.UserAlert{Delivery: models.DeliveryDaily, DigestAt: "soon"}, false},
+		{"instant", models.UserAlert{Delivery: models.DeliveryInstant}, false},
+	}
+
+	for _, tt := range tests {
+		if got := digestDue(&tt.alert, now, time.UTC); got != tt.want {
+			t.Errorf("%s: digestDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollectDueDigests(t *testing.T) {
+	b, _ := newTestBot(t)
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	hourly := &models.UserAlert{ID: "A1", ChatID: 1, Name: "btc", Delivery: models.DeliveryHourly, Enabled: true}
+	paused := &models.UserAlert{ID: "A2", ChatID: 1, Name: "eth", Delivery: models.DeliveryHourly}
+	b.chatAlerts[1] = []*models.UserAlert{hourly, paused}
+	b.pendingDigests["A1"] = []string{"h1", "h2"}
+	b.pendingDigests["A2"] = []string{"h3"}
+
+	due := b.collectDueDigests(now)
+	if len(due) != 1 || due[0].alert.ID != "A1" || len(due[0].hashes) != 2 || due[0].rateLimit {
+		t.Fatalf("collectDueDigests = %+v, want one digest for A1", due)
+	}
+	if !hourly.LastDigestAt.Equal(now) {
+		t.Error("LastDigestAt was not advanced")
+	}
+	if len(b.collectDueDigests(now.Add(time.Minute))) != 0 {
+		t.Error("the same digest was collected twice")
+	}
+}
+
+func TestDigestsWaitForQuietHours(t *testing.T) {
+	b, _ := newTestBot(t)
+	quiet := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC)
+	morning := time.Date(2026, 3, 11, 7, 0, 0, 0, time.UTC)
+
+	hourly := &models.UserAlert{ID: "A1", ChatID: 1, Name: "btc", Delivery: models.DeliveryHourly, Enabled: true, LastDigestAt: quiet.Add(-2 * time.Hour)}
+	daily := &models.UserAlert{ID: "A2", ChatID: 1, Name: "eth", Delivery: models.DeliveryDaily, DigestAt: "00:00", Enabled: true, LastDigestAt: quiet.Add(-12 * time.Hour)}
+	b.chatAlerts[1] = []*models.UserAlert{hourly, daily}
+	b.chatSettings[1] = &models.UserSettings{ChatID: 1, Timezone: "UTC", QuietStart: "23:00", QuietEnd: "07:00"}
+	b.pendingDigests["A1"] = []string{"h1"}
+	b.pendingDigests["A2"] = []string{"h2"}
+
+	for _, now := range []time.Time{quiet, quiet.Add(time.Hour), morning.Add(-time.Minute)} {
+		if due := b.collectDueDigests(now); len(due) != 0 {
+			t.Fatalf("digests sent at %s during quiet hours: %+v", now.Format("15:04"), due)
+		}
+	}
+	if len(b.pendingDigests) != 2 || !hourly.LastDigestAt.Equal(quiet.Add(-2*time.Hour)) {
+		t.Fatal("digests were consumed during quiet hours")
+	}
+
+	if due := b.collectDueDigests(morning); len(due) != 2 {
+		t.Errorf("collectDueDigests after quiet hours = %+v, want both digests", due)
+	}
+}
+
+func TestCollectHeldAlerts(t *testing.T) {
+	b, _ := newTestBot(t)
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	enabled := &models.UserAlert{ID: "A1", ChatID: 1, Enabled: true}
+	paused := &models.UserAlert{ID: "A2", ChatID: 1}
+	b.chatAlerts[1] = []*models.UserAlert{enabled, paused}
+	b.chatSettings[1] = &models.UserSettings{ChatID: 1, Timezone: "UTC", QuietStart: "23:00", QuietEnd: "07:00"}
+	b.heldAlerts[1] = []heldAlert{{alertID: "A1", articleHash: "h1"}, {alertID: "A2", articleHash: "h2"}, {alertID: "gone", articleHash: "h3"}}
+
+	if due := b.collectHeldLocked(now.Add(-4 * time.Hour)); len(due) != 0 {
+		t.Fatalf("held alerts released during quiet hours: %+v", due)
+	}
+
+	due := b.collectHeldLocked(now)
+	if len(due) != 1 || !due[0].rateLimit {
+		t.Fatalf("collectHeldLocked = %+v, want one rate-limited digest", due)
+	}
+	if len(due[0].hashes) != 1 || due[0].hashes[0] != "h1" {
+		t.Errorf("released %v, want only the article held for the enabled alert", due[0].hashes)
+	}
+	if len(b.heldAlerts[1]) != 0 {
+		t.Error("held alerts were not cleared")
+	}
+}
+
+func TestReserveHeldAppliesDailyLimit(t *testing.T) {
+	b, _ := newTestBot(t)
+	now := time.Now()
+
+	// The free plan allows 25 alerts a day: 22 delivered and one still
+	// queued leave room for two more.
+	b.quotas.Commit(5, 22, now)
+	b.reserveAlerts(5, 1, now)
+
+	digest := dueDigest{alert: models.UserAlert{ChatID: 5}, hashes: []string{"h1", "h2", "h3", "h4"}, rateLimit: true}
+	b.reserveHeld(&digest, now)
+	if len(digest.hashes) != 2 || digest.reserved != 2 {
+		t.Errorf("reserveHeld kept %v with %d reserved, want the 2 left in today's allowance", digest.hashes, digest.reserved)
+	}
+	if got := b.quotas.AlertsToday(5, now); got != 22 {
+		t.Errorf("AlertsToday = %d before delivery, want 22", got)
+	}
+}
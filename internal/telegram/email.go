@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// MailingList manages the email notifier's subscribers.
+type MailingList interface {
+	Subscribe(sub models.EmailSubscriber) (models.EmailSubscriber, error)
+	Unsubscribe(address string) (bool, error)
+	Subscribers() []models.EmailSubscriber
+}
+
+func (b *Bot) SetMailingList(list MailingList) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mailingList = list
+}
+
+const emailUsage = `Usage:
+/admin email add [address] [options] - Subscribe or update an address
+/admin email remove [address]
+/admin email list
+
+Options: delivery=instant|daily, at=08:00 (UTC), category=finance,business, min_confidence=0.8, rule="category:crypto AND bitcoin"`
+
+func (b *Bot) handleAdminEmail(chatID int64, text string) {
+	b.mu.RLock()
+	list := b.mailingList
+	b.mu.RUnlock()
+
+	if list == nil {
+		b.sendMessage(chatID, "Email delivery is not configured. Set SMTP_HOST and SMTP_FROM to enable it.")
+		return
+	}
+
+	parts := splitArgs(text)
+	if len(parts) < 3 {
+		b.sendMessage(chatID, emailUsage)
+		return
+	}
+
+	switch parts[2] {
+	case "add":
+		if len(parts) < 4 {
+			b.sendMessage(chatID, emailUsage)
+			return
+		}
+		sub, err := parseEmailOptions(parts[3], parts[4:])
+		if err == nil {
+			sub, err = list.Subscribe(sub)
+		}
+		if err != nil {
+			b.sendMessage(chatID, "Could not subscribe: "+html.EscapeString(err.Error()))
+			return
+		}
+		b.sendMessage(chatID, "Subscribed 📧\n\n"+formatEmailSubscriber(sub))
+	case "remove":
+		if len(parts) < 4 {
+			b.sendMessage(chatID, emailUsage)
+			return
+		}
+		removed, err := list.Unsubscribe(parts[3])
+		if err != nil {
+			log.Printf("Failed to save email subscribers: %v", err)
+		}
+		if !removed {
+			b.sendMessage(chatID, fmt.Sprintf("%s is not subscribed.", html.EscapeString(parts[3])))
+			return
+		}
+		b.sendMessage(chatID, fmt.Sprintf("%s unsubscribed.", html.EscapeString(parts[3])))
+	case "list":
+		subscribers := list.Subscribers()
+		if len(subscribers) == 0 {
+			b.sendMessage(chatID, "No email subscribers yet.")
+			return
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("📧 <b>%d email subscribers</b>\n", len(subscribers)))
+		for _, sub := range subscribers {
+			sb.WriteString("\n" + formatEmailSubscriber(sub) + "\n")
+		}
+		b.sendMessage(chatID, sb.String())
+	default:
+		b.sendMessage(chatID, emailUsage)
+	}
+}
+
+func parseEmailOptions(address string, args []string) (models.EmailSubscriber, error) {
+	sub := models.EmailSubscriber{Address: address}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return sub, fmt.Errorf("expected key=value, got %q", arg)
+		}
+
+		switch strings.ToLower(key) {
+		case "delivery":
+			sub.Delivery = strings.ToLower(value)
+		case "at":
+			sub.DigestAt = value
+		case "category", "categories":
+			sub.Categories = splitList(value)
+		case "min_confidence":
+			confidence, err := parseConfidence(value)
+			if err != nil {
+				return sub, err
+			}
+			sub.MinConfidence = confidence
+		case "rule":
+			sub.Rule = value
+		default:
+			return sub, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return sub, nil
+}
+
+func formatEmailSubscriber(sub models.EmailSubscriber) string {
+	delivery := sub.Delivery
+	if sub.Delivery == models.DeliveryDaily {
+		delivery += " at " + sub.DigestAt + " UTC"
+	}
+
+	line := fmt.Sprintf("<b>%s</b> - %s\nCategories: %s\nMinimum confidence: %s",
+		html.EscapeString(sub.Address), delivery, formatList(sub.Categories), formatConfidence(sub.MinConfidence))
+	if sub.Rule != "" {
+		line += "\nRule: " + html.EscapeString(sub.Rule)
+	}
+	return line
+}
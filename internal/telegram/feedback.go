@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/feedback"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const feedbackPrefix = "fb:"
+
+var feedbackButtons = []struct {
+	verdict string
+	label   string
+}{
+	{models.FeedbackRelevant, "👍 Relevant"},
+	{models.FeedbackIrrelevant, "👎 Irrelevant"},
+	{models.FeedbackWrongCategory, "🏷 Wrong category"},
+}
+
+// feedbackKeyboard builds the buttons under an alert, marking selected once
+// the user has voted.
+func feedbackKeyboard(selected string) *tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, button := range feedbackButtons {
+		label := button.label
+		if button.verdict == selected {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, feedbackPrefix+button.verdict))
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &markup
+}
+
+// handleFeedbackCallback records a vote on an alert. The alert is identified
+// by the message the buttons belong to, so callback data stays short; in a
+// group every member who can see the alert gets their own vote.
+func (b *Bot) handleFeedbackCallback(query *tgbotapi.CallbackQuery) {
+	verdict := strings.TrimPrefix(query.Data, feedbackPrefix)
+	if !validVerdict(verdict) {
+		b.answerCallback(query.ID, "")
+		return
+	}
+
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+
+	b.mu.RLock()
+	delivery, ok := b.findDeliveryLocked(chatID, messageID)
+	store := b.feedback
+	b.mu.RUnlock()
+	if !ok {
+		b.answerCallback(query.ID, "Feedback is no longer available for this alert.")
+		return
+	}
+
+	err := store.Record(models.Feedback{
+		UserID:      query.From.ID,
+		AlertID:     delivery.AlertID,
+		ArticleHash: delivery.ArticleHash,
+		Category:    delivery.Category,
+		Source:      delivery.Source,
+		Verdict:     verdict,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to save feedback: %v", err)
+		b.answerCallback(query.ID, "Sorry, your feedback could not be saved. Please try again.")
+		return
+	}
+
+	b.answerCallback(query.ID, "Thanks for the feedback!")
+	b.enqueueChattable(chatID, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, *feedbackKeyboard(verdict)))
+	log.Printf("Feedback %s on alert %s for article %s", verdict, delivery.AlertID, delivery.ArticleHash)
+}
+
+// findDeliveryLocked looks up the alert behind a sent message, newest first.
+// Callers must hold b.mu.
+func (b *Bot) findDeliveryLocked(chatID int64, messageID int) (models.Delivery, bool) {
+	for i := len(b.deliveries) - 1; i >= 0; i-- {
+		if b.deliveries[i].ChatID == chatID && b.deliveries[i].MessageID == messageID {
+			return b.deliveries[i], true
+		}
+	}
+	return models.Delivery{}, false
+}
+
+func validVerdict(verdict string) bool {
+	for _, button := range feedbackButtons {
+		if button.verdict == verdict {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFeedback replaces the in-memory feedback store, e.g. with one backed
+// by a file.
+func (b *Bot) SetFeedback(store *feedback.Store) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.feedback = store
+}
+
+// FeedbackReport aggregates every vote recorded so far.
+func (b *Bot) FeedbackReport() models.FeedbackReport {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.feedback.Report()
+}
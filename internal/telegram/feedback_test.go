@@ -0,0 +1,36 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestFeedbackCallbackCountsEachGroupMember(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.deliveries = []models.Delivery{{ChatID: -100, MessageID: 7, AlertID: "A1", ArticleHash: "h1", Category: "finance", Source: "reuters"}}
+
+	vote := func(userID int64, messageID int, verdict string) {
+		bot.handleFeedbackCallback(&tgbotapi.CallbackQuery{
+			ID:      "q",
+			From:    &tgbotapi.User{ID: userID},
+			Message: &tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: -100}},
+			Data:    feedbackPrefix + verdict,
+		})
+	}
+
+	vote(1, 7, models.FeedbackRelevant)
+	vote(2, 7, models.FeedbackWrongCategory)
+	vote(2, 7, models.FeedbackIrrelevant)
+	vote(3, 7, "bogus")
+	vote(3, 8, models.FeedbackRelevant)
+
+	report := bot.FeedbackReport()
+	if report.Overall.Total != 2 || report.Overall.Relevant != 1 || report.Overall.Irrelevant != 1 {
+		t.Errorf("overall = %+v", report.Overall)
+	}
+	if report.Categories["finance"].Total != 2 || report.Sources["reuters"].Total != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+)
+
+// matchesAlert requires every option the alert sets to match, while any one
+// value within an option is enough. Rule alerts are evaluated on their own.
+func (b *Bot) matchesAlert(article models.CategorizedArticle, alert *models.UserAlert) bool {
+	if !matchesLanguage(article, alert) || !matchesFilters(article, alert, time.Now()) {
+		return false
+	}
+
+	if alert.Rule != "" {
+		matched, err := rules.Match(alert.Rule, article)
+		if err != nil {
+			log.Printf("Invalid alert rule %s for user %d: %v", alert.ID, alert.UserID, err)
+			return false
+		}
+		return matched
+	}
+
+	// An alert made only of filters (e.g. sentiment=negative) matches
+	// everything that passes them.
+	matchedAny := hasFilters(alert)
+
+	if len(alert.Categories) > 0 {
+		if !matchesCategory(article, alert.Categories) {
+			return false
+		}
+		matchedAny = true
+	}
+
+	if len(alert.Keywords) > 0 {
+		if !matchesKeyword(article, alert.Keywords) {
+			return false
+		}
+		matchedAny = true
+	}
+
+	if len(alert.Tags) > 0 {
+		if !matchesTag(article, alert.Tags) {
+			return false
+		}
+		matchedAny = true
+	}
+
+	if len(alert.TopicEmbedding) > 0 {
+		if !b.matchesTopic(article, alert) {
+			return false
+		}
+		matchedAny = true
+	}
+
+	return matchedAny
+}
+
+func matchesCategory(article models.CategorizedArticle, categories []string) bool {
+	for _, category := range categories {
+		if strings.EqualFold(article.Category, category) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesKeyword(article models.CategorizedArticle, keywords []string) bool {
+	content := strings.ToLower(article.Title + " " + article.Content + " " + article.Metadata["original_title"])
+	for _, keyword := range keywords {
+		if strings.Contains(content, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesTag(article models.CategorizedArticle, tags []string) bool {
+	for _, tag := range tags {
+		for _, articleTag := range article.Tags {
+			if strings.EqualFold(articleTag, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *Bot) matchesTopic(article models.CategorizedArticle, alert *models.UserAlert) bool {
+	if b.vectors == nil {
+		return false
+	}
+
+	threshold := alert.TopicThreshold
+	if threshold == 0 {
+		threshold = b.semanticThreshold
+	}
+
+	similarity, ok := b.vectors.Similarity(article.Hash, alert.TopicEmbedding)
+	return ok && similarity >= threshold
+}
+
+// matchesFilters applies the alert's thresholds, which narrow every alert
+// regardless of whether it uses options or a rule.
+func matchesFilters(article models.CategorizedArticle, alert *models.UserAlert, now time.Time) bool {
+	if alert.MinConfidence > 0 && article.Confidence < alert.MinConfidence {
+		return false
+	}
+
+	if len(alert.Sentiments) > 0 && !containsFold(alert.Sentiments, article.Sentiment) {
+		return false
+	}
+
+	if len(alert.Sources) > 0 && !matchesSource(article, alert.Sources) {
+		return false
+	}
+
+	if len(alert.ExcludeSources) > 0 && matchesSource(article, alert.ExcludeSources) {
+		return false
+	}
+
+	if alert.MaxAge > 0 && !article.PublishedAt.IsZero() && now.Sub(article.PublishedAt) > alert.MaxAge {
+		return false
+	}
+
+	return true
+}
+
+func hasFilters(alert *models.UserAlert) bool {
+	return alert.MinConfidence > 0 || len(alert.Sentiments) > 0 || len(alert.Sources) > 0 ||
+		len(alert.ExcludeSources) > 0 || alert.MaxAge > 0 || len(alert.Languages) > 0
+}
+
+// matchesSource checks both the feed an article came from (treenews,
+// cryptopanic, newsapi) and the outlet reported by that feed.
+func matchesSource(article models.CategorizedArticle, sources []string) bool {
+	return containsFold(sources, article.Source) || containsFold(sources, article.Metadata["source_name"])
+}
+
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLanguage accepts an article in one of the alert's languages, or one
+// whose title and summary were translated into one of them.
+func matchesLanguage(article models.CategorizedArticle, alert *models.UserAlert) bool {
+	if len(alert.Languages) == 0 {
+		return true
+	}
+
+	for _, language := range alert.Languages {
+		if strings.EqualFold(article.Language, language) || strings.EqualFold(article.Metadata["translated_to"], language) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ruleText splits "/alert rule <name> <expression>" into the alert name and
+// the raw expression, preserving the quotes and spacing the parser needs.
+func ruleText(text string) (string, string) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 {
+		return "", ""
+	}
+
+	rest := strings.TrimSpace(text)
+	for _, field := range fields[:3] {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, field))
+	}
+	return fields[2], rest
+}
+
+// formatRuleError points at the offending position in the rule so users can
+// see what the parser tripped over.
+func formatRuleError(rule string, err error) string {
+	parseErr, ok := err.(*rules.ParseError)
+	if !ok {
+		return fmt.Sprintf("Invalid alert rule: %s", html.EscapeString(err.Error()))
+	}
+
+	runes := []rune(rule)
+	pos := parseErr.Pos
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+
+	return fmt.Sprintf("Invalid alert rule: %s\n\n<pre>%s\n%s^</pre>\n\nExample: /alert rule crypto category:crypto AND (bitcoin OR btc) AND NOT \"price prediction\"",
+		html.EscapeString(parseErr.Error()), html.EscapeString(rule), strings.Repeat(" ", pos))
+}
@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func testArticle() models.CategorizedArticle {
+	return models.CategorizedArticle{
+		Article: models.Article{
+			Title:       "Bitcoin ETF inflows hit a record",
+			Content:     "Spot funds took in $1 billion on Tuesday.",
+			Source:      "NewsAPI",
+			Category:    "cryptocurrency",
+			Tags:        []string{"bitcoin", "etf"},
+			Sentiment:   "positive",
+			Language:    "en",
+			PublishedAt: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+			Metadata:    map[string]string{"source_name": "Reuters"},
+		},
+		Confidence: 0.8,
+	}
+}
+
+func TestMatchesFilters(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		alert models.UserAlert
+		want  bool
+	}{
+		{"no filters", models.UserAlert{}, true},
+		{"confidence met", models.UserAlert{MinConfidence: 0.8}, true},
+		{"confidence too low", models.UserAlert{MinConfidence: 0.9}, false},
+		{"sentiment", models.UserAlert{Sentiments: []string{"negative", "Positive"}}, true},
+		{"wrong sentiment", models.UserAlert{Sentiments: []string{"negative"}}, false},
+		{"feed source", models.UserAlert{Sources: []string{"newsapi"}}, true},
+		{"outlet source", models.UserAlert{Sources: []string{"reuters"}}, true},
+		{"other source", models.UserAlert{Sources: []string{"bloomberg"}}, false},
+		{"excluded outlet", models.UserAlert{ExcludeSources: []string{"Reuters"}}, false},
+		{"excluded other", models.UserAlert{ExcludeSources: []string{"bloomberg"}}, true},
+		{"fresh enough", models.UserAlert{MaxAge: 3 * time.Hour}, true},
+		{"too old", models.UserAlert{MaxAge: time.Hour}, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesFilters(testArticle(), &tt.alert, now); got != tt.want {
+			t.Errorf("%s: matchesFilters = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesAlert(t *testing.T) {
+	b := &Bot{}
+
+	tests := []struct {
+		name  string
+		alert models.UserAlert
+		want  bool
+	}{
+		{"empty alert", models.UserAlert{}, false},
+		{"category", models.UserAlert{Categories: []string{"cryptocurrency"}}, true},
+		{"one of the keywords", models.UserAlert{Keywords: []string{"ethereum", "ETF"}}, true},
+		{"every option must match", models.UserAlert{Categories: []string{"cryptocurrency"}, Keywords: []string{"ethereum"}}, false},
+		{"category and tag", models.UserAlert{Categories: []string{"cryptocurrency"}, Tags: []string{"ETF"}}, true},
+		{"filter only", models.UserAlert{Sentiments: []string{"positive"}}, true},
+		{"filter narrows options", models.UserAlert{Categories: []string{"cryptocurrency"}, MinConfidence: 0.9}, false},
+		{"rule", models.UserAlert{Rule: "bitcoin AND tag:etf"}, true},
+		{"rule not matched", models.UserAlert{Rule: "bitcoin -etf"}, false},
+		{"rule with filter", models.UserAlert{Rule: "bitcoin", Sources: []string{"bloomberg"}}, false},
+		{"invalid rule", models.UserAlert{Rule: "bitcoin AND"}, false},
+		{"language", models.UserAlert{Languages: []string{"en"}, Keywords: []string{"bitcoin"}}, true},
+		{"other language", models.UserAlert{Languages: []string{"es"}, Keywords: []string{"bitcoin"}}, false},
+		{"topic without vectors", models.UserAlert{TopicEmbedding: []float64{1, 0}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := b.matchesAlert(testArticle(), &tt.alert); got != tt.want {
+			t.Errorf("%s: matchesAlert = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesTranslatedLanguage(t *testing.T) {
+	article := testArticle()
+	article.Language = "es"
+	article.Metadata["translated_to"] = "en"
+
+	if !matchesLanguage(article, &models.UserAlert{Languages: []string{"EN"}}) {
+		t.Error("an article translated into the alert's language should match")
+	}
+}
+
+func TestRuleText(t *testing.T) {
+	tests := []struct {
+		text, name, rule string
+	}{
+		{`/alert rule crypto category:crypto AND "rate cut"`, "crypto", `category:crypto AND "rate cut"`},
+		{"/alert rule   spaced   bitcoin  OR  eth", "spaced", "bitcoin  OR  eth"},
+		{"/alert rule onlyname", "onlyname", ""},
+		{"/alert rule", "", ""},
+	}
+
+	for _, tt := range tests {
+		name, rule := ruleText(tt.text)
+		if name != tt.name || rule != tt.rule {
+			t.Errorf("ruleText(%q) = %q, %q; want %q, %q", tt.text, name, rule, tt.name, tt.rule)
+		}
+	}
+}
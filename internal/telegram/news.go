@@ -0,0 +1,178 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/cache"
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	"github.com/ObiAU/hfnewsaggregator/internal/rules"
+)
+
+const (
+	defaultLatestCount = 5
+	maxLatestCount     = 20
+	maxSearchResults   = 10
+	articleIDLength    = 8
+	minArticleIDLength = 6
+)
+
+// handleLatest answers /latest [category] [n] [window], e.g. /latest crypto 10 1h.
+func (b *Bot) handleLatest(chatID int64, text string) {
+	articleCache := b.articleCache()
+	if articleCache == nil {
+		b.sendMessage(chatID, "News lookups are not available right now.")
+		return
+	}
+
+	query := cache.Query{Limit: defaultLatestCount}
+	for _, arg := range strings.Fields(text)[1:] {
+		if n, err := strconv.Atoi(arg); err == nil {
+			if n < 1 || n > maxLatestCount {
+				b.sendMessage(chatID, fmt.Sprintf("Choose between 1 and %d articles.", maxLatestCount))
+				return
+			}
+			query.Limit = n
+			continue
+		}
+		if window, err := time.ParseDuration(arg); err == nil && window > 0 {
+			query.Since = time.Now().Add(-window)
+			continue
+		}
+		query.Category = rules.CanonicalCategory(strings.ToLower(arg))
+	}
+
+	articles := articleCache.QueryCategorized(query)
+	if len(articles) == 0 {
+		b.sendMessage(chatID, "No matching articles in the cache. Try another category or a wider window, e.g. /latest crypto 12h")
+		return
+	}
+
+	title := "Latest news"
+	if query.Category != "" {
+		title = "Latest in " + query.Category
+	}
+	b.sendRendered(chatID, formatArticleList(b.renderer, "🕐", title, articles))
+}
+
+func (b *Bot) handleSearch(chatID int64, text string) {
+	articleCache := b.articleCache()
+	if articleCache == nil {
+		b.sendMessage(chatID, "News lookups are not available right now.")
+		return
+	}
+
+	_, query, _ := strings.Cut(text, " ")
+	query = strings.Trim(strings.TrimSpace(query), `"`)
+	if query == "" {
+		b.sendMessage(chatID, "Usage: /search ethereum etf")
+		return
+	}
+
+	articles := articleCache.QueryCategorized(cache.Query{Text: query, Limit: maxSearchResults})
+	if len(articles) == 0 {
+		b.sendMessage(chatID, "No cached articles match that search.")
+		return
+	}
+
+	b.sendRendered(chatID, formatArticleList(b.renderer, "🔎", fmt.Sprintf("Results for %q", query), articles))
+}
+
+// handleArticle accepts both "/article <id>" and the tappable "/article_<id>"
+// form used in article lists.
+func (b *Bot) handleArticle(chatID int64, text string) {
+	articleCache := b.articleCache()
+	if articleCache == nil {
+		b.sendMessage(chatID, "News lookups are not available right now.")
+		return
+	}
+
+	id := strings.TrimPrefix(strings.TrimPrefix(strings.Fields(text)[0], "/article"), "_")
+	if parts := strings.Fields(text); id == "" && len(parts) > 1 {
+		id = parts[1]
+	}
+	id = strings.ToLower(id)
+	if len(id) < minArticleIDLength {
+		b.sendMessage(chatID, "Usage: /article <id> - IDs are shown in /latest and /search results.")
+		return
+	}
+
+	article, ok := articleCache.FindCategorized(id)
+	if !ok {
+		b.sendMessage(chatID, "Article not found. It may have expired from the cache.")
+		return
+	}
+
+	b.sendRendered(chatID, renderArticle(b.renderer, article))
+}
+
+func (b *Bot) articleCache() *cache.Cache {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.articles
+}
+
+func articleID(article models.CategorizedArticle) string {
+	if len(article.Hash) > articleIDLength {
+		return article.Hash[:articleIDLength]
+	}
+	return article.Hash
+}
+
+func formatArticleList(r renderer, icon, title string, articles []models.CategorizedArticle) string {
+	var sb strings.Builder
+	sb.WriteString(r.escape(icon+" ") + r.bold(title) + "\n")
+	for _, article := range articles {
+		line := fmt.Sprintf("\n%s%s\n%s", r.escape("• "), r.link(article.Title, article.URL),
+			r.escape(fmt.Sprintf("%s · %s · %s · /article_%s", articleSource(article), article.Category, timeAgo(article.PublishedAt), articleID(article))))
+		sb.WriteString(line)
+	}
+	return truncateRendered(sb.String(), maxMessageLength)
+}
+
+func renderArticle(r renderer, article models.CategorizedArticle) string {
+	var sb strings.Builder
+	sb.WriteString("📰 " + r.bold(article.Title) + "\n\n")
+	sb.WriteString(r.escape(fmt.Sprintf("📂 Category: %s (%.0f%% confidence)", article.Category, article.Confidence*100)) + "\n")
+	if len(article.Tags) > 0 {
+		sb.WriteString(r.escape("🏷️ Tags: "+strings.Join(article.Tags, ", ")) + "\n")
+	}
+	sb.WriteString(r.escape("😊 Sentiment: "+article.Sentiment) + "\n")
+	if article.Language != "" {
+		sb.WriteString(r.escape("🌐 Language: "+article.Language) + "\n")
+	}
+	if original := article.Metadata["original_title"]; original != "" {
+		sb.WriteString(r.escape("📝 Original title: "+original) + "\n")
+	}
+	sb.WriteString(r.escape(fmt.Sprintf("🕐 Published: %s (%s)", article.PublishedAt.Format("Jan 2 15:04 MST"), timeAgo(article.PublishedAt))) + "\n")
+	sb.WriteString(r.escape("🔗 ") + r.link(articleSource(article), article.URL) + "\n")
+
+	message := sb.String()
+	if article.Summary == "" {
+		return message
+	}
+
+	budget := maxMessageLength - utf8.RuneCountInString(message) - 1
+	return message + "\n" + escapeTruncated(r, article.Summary, budget)
+}
+
+func timeAgo(t time.Time) string {
+	if t.IsZero() {
+		return "unknown time"
+	}
+
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
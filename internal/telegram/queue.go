@@ -0,0 +1,262 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram allows roughly 30 messages per second overall, one per second to
+// a private chat and 20 per minute to a group.
+const (
+	globalSendInterval  = time.Second / 30
+	privateChatInterval = 1 * time.Second
+	groupChatInterval   = 3 * time.Second
+	sendWorkers         = 8
+	maxQueuedPerChat    = 200
+	maxSendAttempts     = 5
+	baseRetryBackoff    = 1 * time.Second
+	maxRetryBackoff     = 30 * time.Second
+	queuePruneInterval  = 1 * time.Minute
+)
+
+var ErrQueueFull = errors.New("telegram send queue is full")
+
+type outboundMessage struct {
+	chatID    int64
+	message   tgbotapi.Chattable
+	onSent    func(tgbotapi.Message)
+	onDropped func(error)
+	attempts  int
+}
+
+type chatQueue struct {
+	messages    []*outboundMessage
+	scheduled   bool
+	nextAllowed time.Time
+}
+
+type sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// outbox delivers messages through a bounded worker pool. Each chat has its
+// own FIFO queue that only one worker drains at a time, so messages to a
+// chat keep their order while different chats are sent in parallel.
+type outbox struct {
+	api    sender
+	ready  chan int64
+	chats  map[int64]*chatQueue
+	mu     sync.Mutex
+	global *time.Ticker
+	done   <-chan struct{}
+	onFail func(chatID int64, err error)
+}
+
+func newOutbox(api sender) *outbox {
+	return &outbox{
+		api:    api,
+		ready:  make(chan int64, 4096),
+		chats:  make(map[int64]*chatQueue),
+		global: time.NewTicker(globalSendInterval),
+	}
+}
+
+func (o *outbox) start(ctx context.Context) {
+	o.mu.Lock()
+	o.done = ctx.Done()
+	o.mu.Unlock()
+
+	for i := 0; i < sendWorkers; i++ {
+		go o.worker(ctx)
+	}
+
+	go func() {
+		prune := time.NewTicker(queuePruneInterval)
+		defer prune.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				o.global.Stop()
+				return
+			case now := <-prune.C:
+				o.pruneIdle(now)
+			}
+		}
+	}()
+}
+
+// pruneIdle forgets chats with nothing queued whose send interval has
+// passed, so the map only holds chats that are actively being sent to.
+func (o *outbox) pruneIdle(now time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for chatID, queue := range o.chats {
+		if len(queue.messages) == 0 && !queue.scheduled && !now.Before(queue.nextAllowed) {
+			delete(o.chats, chatID)
+		}
+	}
+}
+
+func (o *outbox) enqueue(msg *outboundMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queue, ok := o.chats[msg.chatID]
+	if !ok {
+		queue = &chatQueue{}
+		o.chats[msg.chatID] = queue
+	}
+
+	if len(queue.messages) >= maxQueuedPerChat {
+		return ErrQueueFull
+	}
+
+	queue.messages = append(queue.messages, msg)
+	o.scheduleLocked(msg.chatID, queue)
+	return nil
+}
+
+// scheduleLocked hands the chat to a worker once its per-chat interval has
+// elapsed. A chat is only ever scheduled once, which is what keeps a single
+// worker on it at a time. Callers must hold o.mu.
+func (o *outbox) scheduleLocked(chatID int64, queue *chatQueue) {
+	if queue.scheduled || len(queue.messages) == 0 {
+		return
+	}
+	queue.scheduled = true
+
+	done := o.done
+	dispatch := func() {
+		select {
+		case o.ready <- chatID:
+		case <-done:
+		}
+	}
+
+	delay := time.Until(queue.nextAllowed)
+	if delay <= 0 {
+		go dispatch()
+		return
+	}
+	time.AfterFunc(delay, dispatch)
+}
+
+func (o *outbox) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case chatID := <-o.ready:
+			o.process(ctx, chatID)
+		}
+	}
+}
+
+func (o *outbox) process(ctx context.Context, chatID int64) {
+	o.mu.Lock()
+	queue := o.chats[chatID]
+	if queue == nil || len(queue.messages) == 0 {
+		if queue != nil {
+			queue.scheduled = false
+		}
+		o.mu.Unlock()
+		return
+	}
+	msg := queue.messages[0]
+	o.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-o.global.C:
+	}
+
+	sent, err := o.api.Send(msg.message)
+	msg.attempts++
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queue.scheduled = false
+	queue.nextAllowed = time.Now().Add(chatInterval(chatID))
+
+	switch {
+	case err == nil:
+		queue.messages = queue.messages[1:]
+		if msg.onSent != nil {
+			go msg.onSent(sent)
+		}
+	case retryAfter(err) > 0:
+		queue.nextAllowed = time.Now().Add(retryAfter(err))
+		log.Printf("Telegram flood control for chat %d, retrying in %s", chatID, retryAfter(err))
+	case isRetryable(err) && msg.attempts < maxSendAttempts:
+		queue.nextAllowed = time.Now().Add(backoff(msg.attempts))
+		log.Printf("Failed to send telegram message to chat %d (attempt %d): %v", chatID, msg.attempts, err)
+	default:
+		queue.messages = queue.messages[1:]
+		log.Printf("Dropping telegram message to chat %d after %d attempts: %v", chatID, msg.attempts, err)
+		if msg.onDropped != nil {
+			go msg.onDropped(err)
+		}
+		if o.onFail != nil {
+			go o.onFail(chatID, err)
+		}
+	}
+
+	o.scheduleLocked(chatID, queue)
+}
+
+func (o *outbox) pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	total := 0
+	for _, queue := range o.chats {
+		total += len(queue.messages)
+	}
+	return total
+}
+
+func chatInterval(chatID int64) time.Duration {
+	if chatID < 0 {
+		return groupChatInterval
+	}
+	return privateChatInterval
+}
+
+func retryAfter(err error) time.Duration {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == 429 {
+		if apiErr.RetryAfter > 0 {
+			return time.Duration(apiErr.RetryAfter) * time.Second
+		}
+		return baseRetryBackoff
+	}
+	return 0
+}
+
+// isRetryable treats Telegram's 4xx responses (bad request, blocked, chat
+// not found) as permanent and everything else, including network errors,
+// as transient.
+func isRetryable(err error) bool {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500 || apiErr.Code == 0
+	}
+	return true
+}
+
+func backoff(attempt int) time.Duration {
+	delay := baseRetryBackoff << (attempt - 1)
+	if delay > maxRetryBackoff || delay <= 0 {
+		return maxRetryBackoff
+	}
+	return delay
+}
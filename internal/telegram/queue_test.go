@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeSender records sent texts. errs scripts the result of each send per
+// chat, so chats served concurrently don't race for each other's errors.
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []string
+	errs map[int64][]error
+}
+
+func (s *fakeSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := c.(tgbotapi.MessageConfig)
+	if errs := s.errs[config.ChatID]; len(errs) > 0 {
+		s.errs[config.ChatID] = errs[1:]
+		if errs[0] != nil {
+			return tgbotapi.Message{}, errs[0]
+		}
+	}
+	s.sent = append(s.sent, config.Text)
+	return tgbotapi.Message{MessageID: len(s.sent)}, nil
+}
+
+func (s *fakeSender) sentTexts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the outbox")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOutboxKeepsPerChatOrder(t *testing.T) {
+	sender := &fakeSender{}
+	o := newOutbox(sender)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.start(ctx)
+
+	for _, text := range []string{"first", "second"} {
+		if err := o.enqueue(&outboundMessage{chatID: 1, message: tgbotapi.NewMessage(1, text)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o.enqueue(&outboundMessage{chatID: 2, message: tgbotapi.NewMessage(2, "other chat")})
+
+	waitFor(t, 3*time.Second, func() bool { return len(sender.sentTexts()) == 3 })
+
+	var chat1 []string
+	for _, text := range sender.sentTexts() {
+		if text != "other chat" {
+			chat1 = append(chat1, text)
+		}
+	}
+	if chat1[0] != "first" || chat1[1] != "second" {
+		t.Errorf("chat 1 received %v, want first then second", chat1)
+	}
+}
+
+func TestOutboxRetriesAndDrops(t *testing.T) {
+	sender := &fakeSender{errs: map[int64][]error{
+		7:  {&tgbotapi.Error{Code: 502, Message: "Bad Gateway"}},
+		-7: {&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}},
+	}}
+	o := newOutbox(sender)
+
+	failed := make(chan int64, 1)
+	o.onFail = func(chatID int64, err error) { failed <- chatID }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.start(ctx)
+
+	sent := make(chan struct{}, 1)
+	o.enqueue(&outboundMessage{chatID: 7, message: tgbotapi.NewMessage(7, "retried"), onSent: func(tgbotapi.Message) { sent <- struct{}{} }})
+	o.enqueue(&outboundMessage{chatID: -7, message: tgbotapi.NewMessage(-7, "blocked")})
+
+	select {
+	case <-sent:
+	case <-time.After(3 * time.Second):
+		t.Fatal("message was not retried after a 5xx error")
+	}
+	select {
+	case chatID := <-failed:
+		if chatID != -7 {
+			t.Errorf("onFail for chat %d, want -7", chatID)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("a 403 was not treated as permanent")
+	}
+}
+
+func TestOutboxQueueFull(t *testing.T) {
+	o := newOutbox(&fakeSender{})
+	for i := 0; i < maxQueuedPerChat; i++ {
+		if err := o.enqueue(&outboundMessage{chatID: 1, message: tgbotapi.NewMessage(1, "x")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.enqueue(&outboundMessage{chatID: 1, message: tgbotapi.NewMessage(1, "x")}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("got %v, want ErrQueueFull", err)
+	}
+	if o.pending() != maxQueuedPerChat {
+		t.Errorf("pending = %d", o.pending())
+	}
+}
+
+func TestOutboxPrunesIdleChats(t *testing.T) {
+	o := newOutbox(&fakeSender{})
+	now := time.Now()
+	o.chats[1] = &chatQueue{nextAllowed: now.Add(-time.Second)}
+	o.chats[2] = &chatQueue{nextAllowed: now.Add(time.Second)}
+	o.chats[3] = &chatQueue{messages: []*outboundMessage{{chatID: 3}}, scheduled: true}
+	o.chats[4] = &chatQueue{scheduled: true}
+
+	o.pruneIdle(now)
+
+	for chatID, want := range map[int64]bool{1: false, 2: true, 3: true, 4: true} {
+		if _, ok := o.chats[chatID]; ok != want {
+			t.Errorf("chat %d kept = %v, want %v", chatID, ok, want)
+		}
+	}
+}
+
+func TestRetryClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		after     time.Duration
+	}{
+		{"flood control", &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}}, false, 7 * time.Second},
+		{"flood control without hint", &tgbotapi.Error{Code: 429}, false, baseRetryBackoff},
+		{"server error", &tgbotapi.Error{Code: 500}, true, 0},
+		{"blocked", &tgbotapi.Error{Code: 403}, false, 0},
+		{"bad request", &tgbotapi.Error{Code: 400}, false, 0},
+		{"network", errors.New("connection reset"), true, 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.err); got != tt.after {
+			t.Errorf("%s: retryAfter = %s, want %s", tt.name, got, tt.after)
+		}
+		if tt.after == 0 && isRetryable(tt.err) != tt.retryable {
+			t.Errorf("%s: isRetryable = %v, want %v", tt.name, !tt.retryable, tt.retryable)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 6: maxRetryBackoff, 70: maxRetryBackoff}
+	for attempt, want := range tests {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+const defaultUrgentThreshold = 0.9
+
+type heldAlert struct {
+	alertID     string
+	articleHash string
+}
+
+func (b *Bot) handleTimezone(chatID int64, text string) {
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		b.mu.RLock()
+		settings := b.settingsLocked(chatID)
+		b.mu.RUnlock()
+
+		b.sendMessage(chatID, fmt.Sprintf("Your timezone is %s. Set it with /timezone Europe/London", html.EscapeString(timezoneName(settings))))
+		return
+	}
+
+	loc, err := time.LoadLocation(parts[1])
+	if err != nil || parts[1] == "Local" {
+		b.sendMessage(chatID, fmt.Sprintf("Unknown timezone %s. Use an IANA name such as America/New_York or Asia/Singapore.", html.EscapeString(parts[1])))
+		return
+	}
+
+	b.mu.Lock()
+	b.ensureSettingsLocked(chatID).Timezone = loc.String()
+	b.mu.Unlock()
+
+	b.sendMessage(chatID, fmt.Sprintf("Timezone set to %s. Your local time is %s. 🕐",
+		html.EscapeString(loc.String()), time.Now().In(loc).Format("15:04")))
+}
+
+func (b *Bot) handleQuiet(chatID int64, text string) {
+	parts := strings.Fields(text)
+
+	if len(parts) < 2 {
+		b.mu.RLock()
+		settings := b.settingsLocked(chatID)
+		b.mu.RUnlock()
+
+		b.sendMessage(chatID, formatQuietHours(settings))
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	settings := b.ensureSettingsLocked(chatID)
+
+	for _, part := range parts[1:] {
+		switch {
+		case part == "off":
+			settings.QuietStart, settings.QuietEnd = "", ""
+		case strings.HasPrefix(part, "urgent="):
+			threshold, err := strconv.ParseFloat(strings.TrimPrefix(part, "urgent="), 64)
+			if err != nil || threshold <= 0 || threshold > 1 {
+				b.sendMessage(chatID, "Invalid urgency threshold. Use a number between 0 and 1, e.g. /quiet urgent=0.9")
+				return
+			}
+			settings.UrgentThreshold = threshold
+		default:
+			start, end, ok := strings.Cut(part, "-")
+			_, startErr := time.Parse("15:04", start)
+			_, endErr := time.Parse("15:04", end)
+			if !ok || startErr != nil || endErr != nil || start == end {
+				b.sendMessage(chatID, "Invalid quiet hours. Use /quiet 23:00-07:00, /quiet urgent=0.9 or /quiet off")
+				return
+			}
+			settings.QuietStart, settings.QuietEnd = start, end
+		}
+	}
+
+	b.sendMessage(chatID, formatQuietHours(*settings))
+}
+
+func formatQuietHours(settings models.UserSettings) string {
+	if settings.QuietStart == "" {
+		return fmt.Sprintf("Quiet hours are off. Timezone: %s\nSet them with /quiet 23:00-07:00",
+			html.EscapeString(timezoneName(settings)))
+	}
+
+	return fmt.Sprintf("Quiet hours: %s-%s (%s) 🌙\nAlerts with urgency ≥ %.0f%% still come through; the rest arrive as a digest when quiet hours end.",
+		settings.QuietStart, settings.QuietEnd, html.EscapeString(timezoneName(settings)), urgentThreshold(settings)*100)
+}
+
+// settingsLocked returns a copy of the chat's settings, or defaults.
+// Callers must hold b.mu.
+func (b *Bot) settingsLocked(chatID int64) models.UserSettings {
+	if settings, ok := b.chatSettings[chatID]; ok {
+		return *settings
+	}
+	return models.UserSettings{ChatID: chatID}
+}
+
+func (b *Bot) ensureSettingsLocked(chatID int64) *models.UserSettings {
+	settings, ok := b.chatSettings[chatID]
+	if !ok {
+		settings = &models.UserSettings{ChatID: chatID}
+		b.chatSettings[chatID] = settings
+	}
+	return settings
+}
+
+func userLocation(settings models.UserSettings) *time.Location {
+	if settings.Timezone != "" {
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+func timezoneName(settings models.UserSettings) string {
+	if settings.Timezone != "" {
+		return settings.Timezone
+	}
+	return time.Local.String() + " (server default)"
+}
+
+func urgentThreshold(settings models.UserSettings) float64 {
+	if settings.UrgentThreshold > 0 {
+		return settings.UrgentThreshold
+	}
+	return defaultUrgentThreshold
+}
+
+// inQuietHours reports whether now falls inside the user's quiet window,
+// which may wrap past midnight (e.g. 23:00-07:00).
+func inQuietHours(settings models.UserSettings, now time.Time) bool {
+	if settings.QuietStart == "" || settings.QuietEnd == "" {
+		return false
+	}
+
+	start, err := time.Parse("15:04", settings.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", settings.QuietEnd)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(userLocation(settings))
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+// shouldHoldLocked decides whether an instant alert waits for quiet hours
+// to end. Callers must hold b.mu.
+func (b *Bot) shouldHoldLocked(chatID int64, article models.CategorizedArticle, now time.Time) bool {
+	settings := b.settingsLocked(chatID)
+	return inQuietHours(settings, now) && article.Urgency < urgentThreshold(settings)
+}
+
+func (b *Bot) holdAlertLocked(alert *models.UserAlert, hash string) {
+	for _, held := range b.heldAlerts[alert.ChatID] {
+		if held.articleHash == hash {
+			return
+		}
+	}
+	b.heldAlerts[alert.ChatID] = append(b.heldAlerts[alert.ChatID], heldAlert{alertID: alert.ID, articleHash: hash})
+}
+
+// collectHeldLocked releases alerts held for chats whose quiet hours have
+// ended. Articles whose alert has since been deleted or paused are dropped,
+// and the rest still count against the daily alert limit when sent.
+// Callers must hold b.mu.
+func (b *Bot) collectHeldLocked(now time.Time) []dueDigest {
+	var due []dueDigest
+	for chatID, held := range b.heldAlerts {
+		settings := b.settingsLocked(chatID)
+		if inQuietHours(settings, now) {
+			continue
+		}
+		delete(b.heldAlerts, chatID)
+		if settings.Disabled {
+			continue
+		}
+
+		var hashes []string
+		for _, h := range held {
+			if alert := b.alertByIDLocked(h.alertID); alert != nil && alert.ChatID == chatID && alert.Enabled {
+				hashes = append(hashes, h.articleHash)
+			}
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+
+		due = append(due, dueDigest{
+			alert:     models.UserAlert{ChatID: chatID, Name: "Quiet hours"},
+			hashes:    hashes,
+			title:     "While you were away",
+			icon:      "🌙",
+			location:  userLocation(settings),
+			rateLimit: true,
+		})
+	}
+	return due
+}
+
+// reserveHeld trims a quiet-hours digest to the articles the chat's daily
+// alert limit still allows, reserving them until the digest is delivered.
+func (b *Bot) reserveHeld(digest *dueDigest, now time.Time) {
+	digest.reserved = b.reserveAlerts(digest.alert.ChatID, len(digest.hashes), now)
+	digest.hashes = digest.hashes[:digest.reserved]
+}
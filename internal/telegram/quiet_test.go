@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func TestInQuietHours(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 10, hour, minute, 0, 0, time.UTC)
+	}
+	overnight := models.UserSettings{Timezone: "UTC", QuietStart: "23:00", QuietEnd: "07:00"}
+	daytime := models.UserSettings{Timezone: "UTC", QuietStart: "12:00", QuietEnd: "13:30"}
+
+	tests := []struct {
+		name     string
+		settings models.UserSettings
+		now      time.Time
+		want     bool
+	}{
+		{"off", models.UserSettings{Timezone: "UTC"}, at(2, 0), false},
+		{"overnight start", overnight, at(23, 0), true},
+		{"overnight after midnight", overnight, at(3, 15), true},
+		{"overnight end is exclusive", overnight, at(7, 0), false},
+		{"overnight daytime", overnight, at(15, 0), false},
+		{"daytime inside", daytime, at(13, 29), true},
+		{"daytime before", daytime, at(11, 59), false},
+		{"daytime end", daytime, at(13, 30), false},
+		// 23:30 UTC is 08:30 the next morning in Tokyo.
+		{"user timezone", models.UserSettings{Timezone: tokyo.String(), QuietStart: "23:00", QuietEnd: "07:00"}, at(23, 30), false},
+		{"user timezone quiet", models.UserSettings{Timezone: tokyo.String(), QuietStart: "23:00", QuietEnd: "07:00"}, at(15, 0), true},
+		{"invalid clock", models.UserSettings{Timezone: "UTC", QuietStart: "late", QuietEnd: "07:00"}, at(2, 0), false},
+	}
+
+	for _, tt := range tests {
+		if got := inQuietHours(tt.settings, tt.now); got != tt.want {
+			t.Errorf("%s: inQuietHours = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShouldHoldRespectsUrgency(t *testing.T) {
+	b, _ := newTestBot(t)
+	b.chatSettings[1] = &models.UserSettings{ChatID: 1, Timezone: "UTC", QuietStart: "00:00", QuietEnd: "23:59", UrgentThreshold: 0.8}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		urgency float64
+		want    bool
+	}{
+		{0.2, true},
+		{0.79, true},
+		{0.8, false},
+		{1, false},
+	}
+
+	for _, tt := range tests {
+		article := models.CategorizedArticle{Urgency: tt.urgency}
+		if got := b.shouldHoldLocked(1, article, now); got != tt.want {
+			t.Errorf("urgency %.2f: shouldHold = %v, want %v", tt.urgency, got, tt.want)
+		}
+	}
+	if b.shouldHoldLocked(2, models.CategorizedArticle{}, now) {
+		t.Error("a chat without quiet hours should never hold alerts")
+	}
+}
+
+func TestUrgentThresholdDefault(t *testing.T) {
+	if got := urgentThreshold(models.UserSettings{}); got != defaultUrgentThreshold {
+		t.Errorf("urgentThreshold = %v, want the default %v", got, defaultUrgentThreshold)
+	}
+}
@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/quota"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (b *Bot) SetQuotas(quotas *quota.Store) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.quotas = quotas
+}
+
+func (b *Bot) quotaStore() *quota.Store {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.quotas
+}
+
+// tier returns the plan of a chat. Bot admins always get the admin tier in
+// their private chat; other chats use the tier stored for their ID.
+func (b *Bot) tier(chatID int64) string {
+	b.mu.RLock()
+	quotas := b.quotas
+	isAdmin := b.admins[chatID]
+	b.mu.RUnlock()
+
+	if isAdmin {
+		return quota.TierAdmin
+	}
+	return quotas.Tier(chatID)
+}
+
+func (b *Bot) limits(chatID int64) quota.Limits {
+	return quota.LimitsFor(b.tier(chatID))
+}
+
+// reserveAlerts sets aside up to n of the chat's daily alerts for messages
+// about to be queued and tells the chat once when the limit is reached. The
+// reservation is charged by commitAlerts once the message is delivered.
+func (b *Bot) reserveAlerts(chatID int64, n int, now time.Time) int {
+	tier := b.tier(chatID)
+	limit := quota.LimitsFor(tier).AlertsPerDay
+	granted, notify := b.quotaStore().Reserve(chatID, n, limit, now)
+	if notify {
+		b.sendMessage(chatID, fmt.Sprintf(
+			"You've reached today's limit of %d alerts on the %s plan. 📭\n\n"+
+				"Alerts pick up again at 00:00 UTC. Digests still arrive as scheduled, and /latest shows what you missed.",
+			limit, tier))
+	}
+	return granted
+}
+
+// chargeOnDelivery returns the outbound message callbacks that settle a
+// reservation of n alerts: charged when sent, returned when dropped.
+func (b *Bot) chargeOnDelivery(chatID int64, n int) (onSent func(tgbotapi.Message), onDropped func(error)) {
+	quotas := b.quotaStore()
+	onSent = func(tgbotapi.Message) { quotas.Commit(chatID, n, time.Now()) }
+	onDropped = func(error) { quotas.Release(chatID, n) }
+	return onSent, onDropped
+}
+
+func (b *Bot) upgradeMessage(chatID int64, feature string) string {
+	return fmt.Sprintf("%s are not included in the %s plan. Ask the bot's operator about upgrading to pro.", feature, b.tier(chatID))
+}
+
+func (b *Bot) handleUsage(chatID int64) {
+	b.mu.RLock()
+	quotas := b.quotas
+	rules := len(b.chatAlerts[chatID])
+	b.mu.RUnlock()
+
+	tier := b.tier(chatID)
+	limits := quota.LimitsFor(tier)
+
+	perDay := "unlimited"
+	if limits.AlertsPerDay > 0 {
+		perDay = fmt.Sprintf("%d", limits.AlertsPerDay)
+	}
+	ai := "not included"
+	if limits.AI {
+		ai = "included"
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("📈 <b>Plan: %s</b>\n\nAlerts today: %d of %s\nAlert rules: %d of %d\nAI features (/ask, topics, digest overviews): %s",
+		tier, quotas.AlertsToday(chatID, time.Now()), perDay, rules, limits.Alerts, ai))
+}
+
+// sendCharged sends rendered content that counts as n alerts once it is
+// delivered.
+func (b *Bot) sendCharged(chatID int64, text string, n int) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = b.renderer.parseMode()
+	msg.DisableWebPagePreview = true
+
+	onSent, onDropped := b.chargeOnDelivery(chatID, n)
+	err := b.outbox.enqueue(&outboundMessage{
+		chatID:    chatID,
+		message:   msg,
+		onSent:    onSent,
+		onDropped: onDropped,
+	})
+	if err != nil {
+		onDropped(err)
+		log.Printf("Failed to send telegram message: %v", err)
+	}
+}
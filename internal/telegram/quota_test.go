@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestAlertsChargedOnDelivery(t *testing.T) {
+	bot, _ := newTestBot(t)
+	sender := &fakeSender{errs: map[int64][]error{5: {nil, &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}}}}
+	bot.outbox = newOutbox(sender)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bot.outbox.start(ctx)
+
+	now := time.Now()
+	if granted := bot.reserveAlerts(5, 2, now); granted != 2 {
+		t.Fatalf("reserved %d alerts, want 2", granted)
+	}
+	bot.sendCharged(5, "delivered", 2)
+	waitFor(t, time.Second, func() bool { return bot.quotas.AlertsToday(5, now) == 2 })
+
+	bot.reserveAlerts(5, 3, now)
+	bot.sendCharged(5, "dropped", 3)
+	waitFor(t, 3*time.Second, func() bool {
+		bot.outbox.mu.Lock()
+		defer bot.outbox.mu.Unlock()
+		return len(bot.outbox.chats[5].messages) == 0
+	})
+
+	// The free plan allows 25 a day; the dropped message must not use any.
+	waitFor(t, time.Second, func() bool {
+		granted, _ := bot.quotas.Reserve(5, 30, 25, now)
+		bot.quotas.Release(5, granted)
+		return granted == 23
+	})
+	if got := bot.quotas.AlertsToday(5, now); got != 2 {
+		t.Errorf("AlertsToday = %d, want 2", got)
+	}
+}
@@ -0,0 +1,166 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram rejects messages longer than 4096 characters.
+const maxMessageLength = 4096
+
+// renderer formats article and user content for one of Telegram's parse
+// modes. Every method escapes its arguments, so callers pass raw text.
+type renderer interface {
+	parseMode() string
+	escape(text string) string
+	bold(text string) string
+	italic(text string) string
+	link(text, url string) string
+}
+
+func newRenderer(parseMode string) renderer {
+	switch strings.ToLower(parseMode) {
+	case "", "html":
+		return htmlRenderer{}
+	case "markdownv2", "markdown":
+		return markdownV2Renderer{}
+	default:
+		return nil
+	}
+}
+
+type htmlRenderer struct{}
+
+func (htmlRenderer) parseMode() string { return tgbotapi.ModeHTML }
+
+func (htmlRenderer) escape(text string) string {
+	return html.EscapeString(text)
+}
+
+func (r htmlRenderer) bold(text string) string {
+	return "<b>" + r.escape(text) + "</b>"
+}
+
+func (r htmlRenderer) italic(text string) string {
+	return "<i>" + r.escape(text) + "</i>"
+}
+
+func (r htmlRenderer) link(text, url string) string {
+	if !safeURL(url) {
+		return r.escape(text)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), r.escape(text))
+}
+
+type markdownV2Renderer struct{}
+
+func (markdownV2Renderer) parseMode() string { return tgbotapi.ModeMarkdownV2 }
+
+func (markdownV2Renderer) escape(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func (r markdownV2Renderer) bold(text string) string {
+	return "*" + r.escape(text) + "*"
+}
+
+func (r markdownV2Renderer) italic(text string) string {
+	return "_" + r.escape(text) + "_"
+}
+
+func (r markdownV2Renderer) link(text, url string) string {
+	if !safeURL(url) {
+		return r.escape(text)
+	}
+	return "[" + r.escape(text) + "](" + markdownURLEscaper.Replace(url) + ")"
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// Inside the (...) part of a link only ) and \ need escaping.
+var markdownURLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+
+func safeURL(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// renderAlert builds an instant alert in the user's layout. If the result
+// would exceed Telegram's limit the summary is shortened to fit.
+func renderAlert(r renderer, layout string, article models.CategorizedArticle, alert *models.UserAlert) string {
+	build := alertLayout(r, layout, article, alert)
+
+	message := build("")
+	if article.Summary == "" {
+		return truncateRendered(message, maxMessageLength)
+	}
+
+	// Growing the summary by one character shows how often the layout
+	// repeats it; whatever else it adds around the summary is fixed.
+	withSummary := utf8.RuneCountInString(build("x"))
+	uses := utf8.RuneCountInString(build("xx")) - withSummary
+	if uses <= 0 {
+		return truncateRendered(message, maxMessageLength)
+	}
+
+	budget := (maxMessageLength - withSummary + uses) / uses
+	if budget <= 0 {
+		return truncateRendered(message, maxMessageLength)
+	}
+	return truncateRendered(build(escapeTruncated(r, article.Summary, budget)), maxMessageLength)
+}
+
+// escapeTruncated escapes text rune by rune, stopping (with an ellipsis)
+// before the escaped output would exceed limit runes. Cutting raw runes
+// rather than the escaped string keeps entities and escapes intact.
+func escapeTruncated(r renderer, text string, limit int) string {
+	escaped := r.escape(text)
+	if utf8.RuneCountInString(escaped) <= limit {
+		return escaped
+	}
+
+	ellipsis := r.escape("…")
+	limit -= utf8.RuneCountInString(ellipsis)
+
+	var sb strings.Builder
+	length := 0
+	for _, char := range text {
+		part := r.escape(string(char))
+		n := utf8.RuneCountInString(part)
+		if length+n > limit {
+			break
+		}
+		sb.WriteString(part)
+		length += n
+	}
+	return strings.TrimRight(sb.String(), " \n") + ellipsis
+}
+
+// truncateRendered is a last resort for messages with nothing left to
+// shorten; it cuts on a line boundary so no markup is split.
+func truncateRendered(message string, limit int) string {
+	if utf8.RuneCountInString(message) <= limit {
+		return message
+	}
+
+	var sb strings.Builder
+	length := 0
+	for _, line := range strings.SplitAfter(message, "\n") {
+		n := utf8.RuneCountInString(line)
+		if length+n > limit {
+			break
+		}
+		sb.WriteString(line)
+		length += n
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func TestNewRenderer(t *testing.T) {
+	tests := map[string]string{"": "HTML", "html": "HTML", "MarkdownV2": "MarkdownV2", "markdown": "MarkdownV2"}
+	for mode, want := range tests {
+		if r := newRenderer(mode); r == nil || r.parseMode() != want {
+			t.Errorf("newRenderer(%q) = %v, want %s", mode, r, want)
+		}
+	}
+	if newRenderer("bbcode") != nil {
+		t.Error("unknown parse mode should return nil")
+	}
+}
+
+func TestRendererEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		r    renderer
+		got  func(renderer) string
+		want string
+	}{
+		{"html escape", htmlRenderer{}, func(r renderer) string { return r.escape(`<b>AT&T</b> "x"`) }, "&lt;b&gt;AT&amp;T&lt;/b&gt; &#34;x&#34;"},
+		{"html bold", htmlRenderer{}, func(r renderer) string { return r.bold("a<b") }, "<b>a&lt;b</b>"},
+		{"html link", htmlRenderer{}, func(r renderer) string { return r.link("R&D", `https://x.test/?a=1&b="2"`) }, `<a href="https://x.test/?a=1&amp;b=&#34;2&#34;">R&amp;D</a>`},
+		{"html unsafe link", htmlRenderer{}, func(r renderer) string { return r.link("click", "javascript:alert(1)") }, "click"},
+		{"markdown escape", markdownV2Renderer{}, func(r renderer) string { return r.escape("1.5% (est.) - [draft]_*") }, `1\.5% \(est\.\) \- \[draft\]\_\*`},
+		{"markdown italic", markdownV2Renderer{}, func(r renderer) string { return r.italic("a_b") }, `_a\_b_`},
+		{"markdown link", markdownV2Renderer{}, func(r renderer) string { return r.link("wiki", `https://x.test/a_(b)`) }, `[wiki](https://x.test/a_(b\))`},
+		{"markdown unsafe link", markdownV2Renderer{}, func(r renderer) string { return r.link("a.b", "ftp://x.test") }, `a\.b`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.got(tt.r); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeTruncatedKeepsEntitiesWhole(t *testing.T) {
+	r := htmlRenderer{}
+	got := escapeTruncated(r, strings.Repeat("&", 10), 14)
+
+	if utf8.RuneCountInString(got) > 14 {
+		t.Errorf("got %d runes, want at most 14", utf8.RuneCountInString(got))
+	}
+	if want := "&amp;&amp;…"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := escapeTruncated(r, "short", 100); got != "short" {
+		t.Errorf("short text changed to %q", got)
+	}
+}
+
+func TestTruncateRenderedCutsOnLines(t *testing.T) {
+	message := "<b>one</b>\n<b>two</b>\n<b>three</b>"
+	if got := truncateRendered(message, 25); got != "<b>one</b>\n<b>two</b>\n" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateRendered(message, 100); got != message {
+		t.Errorf("message under the limit changed to %q", got)
+	}
+}
+
+func TestRenderAlertFitsMessageLimit(t *testing.T) {
+	article := testArticle()
+	article.URL = "https://example.com/a"
+	article.Summary = strings.Repeat("<AT&T> ", 2000)
+	alert := &models.UserAlert{ID: "A1", Name: "btc"}
+
+	for _, r := range []renderer{htmlRenderer{}, markdownV2Renderer{}} {
+		for _, layout := range []string{layoutFull, layoutCompact, layoutHeadline, "{summary}\n{summary}\n{url}"} {
+			message := renderAlert(r, layout, article, alert)
+			if n := utf8.RuneCountInString(message); n > maxMessageLength {
+				t.Errorf("%s %q: %d runes", r.parseMode(), layout, n)
+			}
+			if strings.Contains(message, "<AT&T>") {
+				t.Errorf("%s %q: summary was not escaped", r.parseMode(), layout)
+			}
+		}
+	}
+
+	message := renderAlert(htmlRenderer{}, layoutFull, article, alert)
+	if !strings.Contains(message, "…") || !strings.Contains(message, "🔔 Alert: btc (A1)") {
+		t.Errorf("full layout lost its ellipsis or footer:\n%s", message[len(message)-200:])
+	}
+}
+
+func TestNewBotUsesParseMode(t *testing.T) {
+	bot, _ := newTestBot(t)
+	if bot.renderer == nil || bot.renderer.parseMode() != "HTML" {
+		t.Errorf("renderer = %v, want the HTML renderer", bot.renderer)
+	}
+}
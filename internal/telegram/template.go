@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+// Alert layouts a user can pick with /format. Anything else stored in
+// UserSettings.AlertTemplate is a custom template.
+const (
+	layoutFull        = "full"
+	layoutCompact     = "compact"
+	layoutHeadline    = "headline"
+	maxTemplateLength = 1000
+)
+
+var templateFields = []string{"title", "category", "tags", "sentiment", "confidence", "source", "url", "summary", "alert"}
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+func isTemplateField(name string) bool {
+	for _, field := range templateFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isLayout(value string) bool {
+	return value == layoutFull || value == layoutCompact || value == layoutHeadline
+}
+
+// validateTemplate checks a custom template before it is saved so a typo is
+// reported to the user instead of showing up in every alert.
+func validateTemplate(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return errors.New("template is empty")
+	}
+	if utf8.RuneCountInString(tmpl) > maxTemplateLength {
+		return fmt.Errorf("template is longer than %d characters", maxTemplateLength)
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(tmpl, -1) {
+		if !isTemplateField(strings.ToLower(strings.TrimSpace(match[1]))) {
+			return fmt.Errorf("unknown field {%s}", match[1])
+		}
+	}
+
+	rest := placeholderPattern.ReplaceAllString(tmpl, "")
+	if strings.ContainsAny(rest, "{}") {
+		return errors.New("unbalanced { or }")
+	}
+	if !placeholderPattern.MatchString(tmpl) {
+		return errors.New("template must use at least one field, e.g. {title}")
+	}
+	return nil
+}
+
+func (b *Bot) handleFormat(chatID int64, text string) {
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		b.mu.RLock()
+		settings := b.settingsLocked(chatID)
+		b.mu.RUnlock()
+
+		b.sendMessage(chatID, fmt.Sprintf("Alert format: %s\n\n%s", html.EscapeString(formatName(settings.AlertTemplate)), formatUsage))
+		return
+	}
+
+	choice := strings.ToLower(parts[1])
+	switch {
+	case isLayout(choice):
+	case choice == "custom":
+		_, tmpl, _ := strings.Cut(text, parts[1])
+		choice = strings.ReplaceAll(strings.TrimSpace(tmpl), `\n`, "\n")
+		if err := validateTemplate(choice); err != nil {
+			b.sendMessage(chatID, fmt.Sprintf("Invalid template: %s\n\n%s", html.EscapeString(err.Error()), formatUsage))
+			return
+		}
+	default:
+		b.sendMessage(chatID, formatUsage)
+		return
+	}
+
+	b.mu.Lock()
+	b.ensureSettingsLocked(chatID).AlertTemplate = choice
+	b.mu.Unlock()
+
+	b.sendMessage(chatID, fmt.Sprintf("Alert format set to %s. Preview:", html.EscapeString(formatName(choice))))
+	b.sendRendered(chatID, renderAlert(b.renderer, choice, sampleArticle(), &models.UserAlert{ID: "A1", Name: "example"}))
+}
+
+const formatUsage = `Choose how alerts look:
+/format full - Everything, including the summary
+/format compact - Title, category, sentiment and link
+/format headline - Title and link only
+/format custom {title} ({source})\n{summary}\n{url}
+
+Template fields: {title}, {category}, {tags}, {sentiment}, {confidence}, {source}, {url}, {summary}, {alert}`
+
+func formatName(template string) string {
+	switch {
+	case template == "":
+		return layoutFull
+	case isLayout(template):
+		return template
+	default:
+		return "custom: " + template
+	}
+}
+
+func sampleArticle() models.CategorizedArticle {
+	var article models.CategorizedArticle
+	article.Title = "Bitcoin ETF inflows hit a monthly record"
+	article.URL = "https://example.com/bitcoin-etf-inflows"
+	article.Source = "example"
+	article.PublishedAt = time.Now()
+	article.Category = "cryptocurrency"
+	article.Tags = []string{"bitcoin", "etf"}
+	article.Sentiment = "positive"
+	article.Confidence = 0.93
+	article.Summary = "Spot bitcoin funds took in more new money this month than in any month since launch."
+	return article
+}
+
+// alertLayout returns a builder for the chosen layout. The builder takes the
+// already escaped summary so renderAlert can shorten it to fit.
+func alertLayout(r renderer, layout string, article models.CategorizedArticle, alert *models.UserAlert) func(summary string) string {
+	source := articleSource(article)
+	alertLabel := fmt.Sprintf("%s (%s)", alert.Name, alert.ID)
+
+	switch layout {
+	case "", layoutFull:
+		return func(summary string) string {
+			var sb strings.Builder
+			sb.WriteString(r.escape("🚨 News Alert") + "\n\n")
+			sb.WriteString("📰 " + r.bold(article.Title) + "\n\n")
+			sb.WriteString(r.escape("📂 Category: "+article.Category) + "\n")
+			if len(article.Tags) > 0 {
+				sb.WriteString(r.escape("🏷️ Tags: "+strings.Join(article.Tags, ", ")) + "\n")
+			}
+			sb.WriteString(r.escape("😊 Sentiment: "+article.Sentiment) + "\n")
+			sb.WriteString(r.escape(fmt.Sprintf("📊 Confidence: %.1f%%", article.Confidence*100)) + "\n")
+			if summary != "" {
+				sb.WriteString("\n" + r.escape("📝 Summary: ") + summary + "\n")
+			}
+			sb.WriteString("\n" + r.escape("🔗 ") + r.link("Read more on "+source, article.URL) + "\n")
+			sb.WriteString(r.escape("🔔 Alert: " + alertLabel))
+			return sb.String()
+		}
+	case layoutCompact:
+		return func(string) string {
+			return "📰 " + r.bold(article.Title) + "\n" +
+				r.escape(fmt.Sprintf("📂 %s · %s · %.0f%%", article.Category, article.Sentiment, article.Confidence*100)) + "\n" +
+				r.escape("🔗 ") + r.link(source, article.URL) + r.escape(" · 🔔 "+alert.Name)
+		}
+	case layoutHeadline:
+		return func(string) string {
+			return r.bold(article.Title) + r.escape(" - ") + r.link(source, article.URL)
+		}
+	default:
+		return func(summary string) string {
+			return renderTemplate(r, layout, article, source, alertLabel, summary)
+		}
+	}
+}
+
+func renderTemplate(r renderer, tmpl string, article models.CategorizedArticle, source, alertLabel, summary string) string {
+	values := map[string]string{
+		"title":      r.escape(article.Title),
+		"category":   r.escape(article.Category),
+		"tags":       r.escape(strings.Join(article.Tags, ", ")),
+		"sentiment":  r.escape(article.Sentiment),
+		"confidence": r.escape(fmt.Sprintf("%.0f%%", article.Confidence*100)),
+		"source":     r.escape(source),
+		"url":        r.escape(article.URL),
+		"summary":    summary,
+		"alert":      r.escape(alertLabel),
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(tmpl, -1) {
+		sb.WriteString(r.escape(tmpl[last:loc[0]]))
+		sb.WriteString(values[strings.ToLower(strings.TrimSpace(tmpl[loc[2]:loc[3]]))])
+		last = loc[1]
+	}
+	sb.WriteString(r.escape(tmpl[last:]))
+	return sb.String()
+}
+
+func articleSource(article models.CategorizedArticle) string {
+	if name := article.Metadata["source_name"]; name != "" {
+		return name
+	}
+	return article.Source
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr string
+	}{
+		{"{title} ({source})\n{url}", ""},
+		{"{ Title } {SUMMARY}", ""},
+		{"", "template is empty"},
+		{"just text", "at least one field"},
+		{"{title} {author}", "unknown field {author}"},
+		{"{title} {", "unbalanced"},
+		{"{title}}", "unbalanced"},
+		{strings.Repeat("{title}", 200), "longer than"},
+	}
+
+	for _, tt := range tests {
+		err := validateTemplate(tt.tmpl)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("validateTemplate(%q) = %v", tt.tmpl, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("validateTemplate(%q) = %v, want %q", tt.tmpl, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	article := testArticle()
+	article.Title = "S&P <500>"
+	article.URL = "https://example.com/?a=1&b=2"
+	alert := &models.UserAlert{ID: "A2", Name: "markets"}
+
+	tests := []struct {
+		r    renderer
+		tmpl string
+		want string
+	}{
+		{htmlRenderer{}, "<{title}> from {source} [{ALERT}]", "&lt;S&amp;P &lt;500&gt;&gt; from Reuters [markets (A2)]"},
+		{htmlRenderer{}, "{category}: {tags}, {confidence}\n{url}", "cryptocurrency: bitcoin, etf, 80%\nhttps://example.com/?a=1&amp;b=2"},
+		{markdownV2Renderer{}, "{title}! {sentiment}", `S&P <500\>\! positive`},
+	}
+
+	for _, tt := range tests {
+		if got := renderAlert(tt.r, tt.tmpl, article, alert); got != tt.want {
+			t.Errorf("%s %q = %q, want %q", tt.r.parseMode(), tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestFormatName(t *testing.T) {
+	tests := map[string]string{"": "full", "compact": "compact", "{title}": "custom: {title}"}
+	for tmpl, want := range tests {
+		if got := formatName(tmpl); got != want {
+			t.Errorf("formatName(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+}
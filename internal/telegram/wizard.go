@@ -0,0 +1,296 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	wizardTimeout = 15 * time.Minute
+	wizardPrefix  = "wz:"
+)
+
+var alertCategories = []string{
+	"politics", "technology", "cryptocurrency", "finance", "sports",
+	"entertainment", "health", "science", "world", "business",
+}
+
+var wizardConfidences = []float64{0, 0.5, 0.7, 0.8, 0.9}
+
+type wizardStep int
+
+const (
+	stepCategories wizardStep = iota
+	stepKeywords
+	stepConfidence
+	stepConfirm
+)
+
+// alertWizard is an in-progress /alert new flow. Each user has at most one.
+type alertWizard struct {
+	name          string
+	replace       bool
+	chatID        int64
+	step          wizardStep
+	categories    map[string]bool
+	keywords      []string
+	minConfidence float64
+	updatedAt     time.Time
+}
+
+func (b *Bot) handleAlertWizard(userID, chatID int64, name string) {
+	replace := false
+	if name == "" {
+		name, replace = defaultAlertName, true
+	}
+	if !validAlertName(name) {
+		b.sendMessage(chatID, "Alert names may only contain letters, digits, '-' and '_' (max 32 characters).")
+		return
+	}
+
+	wizard := &alertWizard{
+		name:       name,
+		replace:    replace,
+		chatID:     chatID,
+		step:       stepCategories,
+		categories: make(map[string]bool),
+		updatedAt:  time.Now(),
+	}
+
+	b.mu.Lock()
+	b.wizards[userID] = wizard
+	b.mu.Unlock()
+
+	text, markup := renderWizard(wizard)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = markup
+	b.enqueueChattable(chatID, msg)
+}
+
+// wizardLocked returns the user's active wizard, discarding it once it has
+// been idle too long. Callers must hold b.mu.
+func (b *Bot) wizardLocked(userID int64, now time.Time) *alertWizard {
+	wizard, ok := b.wizards[userID]
+	if !ok {
+		return nil
+	}
+	if now.Sub(wizard.updatedAt) > wizardTimeout {
+		delete(b.wizards, userID)
+		return nil
+	}
+	return wizard
+}
+
+// handleWizardReply takes the keywords a user types while the wizard waits
+// for them. It reports false when no wizard is waiting for text.
+func (b *Bot) handleWizardReply(userID, chatID int64, text string) bool {
+	b.mu.Lock()
+	wizard := b.wizardLocked(userID, time.Now())
+	if wizard == nil || wizard.step != stepKeywords || wizard.chatID != chatID {
+		b.mu.Unlock()
+		return false
+	}
+
+	wizard.keywords = append(wizard.keywords, splitList(text)...)
+	wizard.step = stepConfidence
+	wizard.updatedAt = time.Now()
+	text, markup := renderWizard(wizard)
+	b.mu.Unlock()
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = markup
+	b.enqueueChattable(chatID, msg)
+	return true
+}
+
+func (b *Bot) handleWizardCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+	chatID := query.Message.Chat.ID
+	action, value, _ := strings.Cut(strings.TrimPrefix(query.Data, wizardPrefix), ":")
+
+	b.mu.Lock()
+	wizard := b.wizardLocked(userID, time.Now())
+	if wizard == nil || wizard.chatID != chatID {
+		b.mu.Unlock()
+		b.answerCallback(query.ID, "This setup has expired. Start again with /alert new")
+		return
+	}
+	wizard.updatedAt = time.Now()
+
+	switch action {
+	case "cat":
+		if isAlertCategory(value) {
+			wizard.categories[value] = !wizard.categories[value]
+		}
+	case "next":
+		if wizard.step < stepConfirm {
+			wizard.step++
+		}
+	case "back":
+		if wizard.step > stepCategories {
+			wizard.step--
+		}
+	case "conf":
+		if confidence, err := strconv.ParseFloat(value, 64); err == nil && confidence >= 0 && confidence <= 1 {
+			wizard.minConfidence = confidence
+			wizard.step = stepConfirm
+		}
+	case "clear":
+		wizard.keywords = nil
+	case "cancel":
+		delete(b.wizards, userID)
+		b.mu.Unlock()
+
+		b.answerCallback(query.ID, "Cancelled")
+		b.editWizard(chatID, query.Message.MessageID, "Alert setup cancelled.", nil)
+		return
+	case "save":
+		delete(b.wizards, userID)
+		b.mu.Unlock()
+
+		b.answerCallback(query.ID, "")
+		b.saveWizard(ctx, userID, chatID, query.Message.MessageID, wizard)
+		return
+	}
+
+	text, markup := renderWizard(wizard)
+	b.mu.Unlock()
+
+	b.answerCallback(query.ID, "")
+	b.editWizard(chatID, query.Message.MessageID, text, markup)
+}
+
+// saveWizard turns the collected answers into options and runs them through
+// the same parser as the text command, so both paths validate identically.
+func (b *Bot) saveWizard(ctx context.Context, userID, chatID int64, messageID int, wizard *alertWizard) {
+	args := wizardOptions(wizard)
+	if len(args) == 0 {
+		b.editWizard(chatID, messageID, "Pick at least one category or keyword. Start again with /alert new", nil)
+		return
+	}
+
+	b.editWizard(chatID, messageID, "Saving your alert…", nil)
+	b.handleAlertSet(ctx, userID, chatID, wizard.name, args, wizard.replace)
+}
+
+func wizardOptions(wizard *alertWizard) []string {
+	var args []string
+	if categories := selectedCategories(wizard); len(categories) > 0 {
+		args = append(args, "category="+strings.Join(categories, ","))
+	}
+	if len(wizard.keywords) > 0 {
+		args = append(args, "keywords="+strings.Join(wizard.keywords, ","))
+	}
+	if wizard.minConfidence > 0 {
+		args = append(args, fmt.Sprintf("min_confidence=%g", wizard.minConfidence))
+	}
+	return args
+}
+
+func (b *Bot) editWizard(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = markup
+	b.enqueueChattable(chatID, edit)
+}
+
+func renderWizard(wizard *alertWizard) (string, *tgbotapi.InlineKeyboardMarkup) {
+	header := fmt.Sprintf("⚙️ <b>New alert: %s</b>\n\n", html.EscapeString(wizard.name))
+	cancel := tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", wizardPrefix+"cancel")
+	back := tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", wizardPrefix+"back")
+	next := tgbotapi.NewInlineKeyboardButtonData("Next ➡️", wizardPrefix+"next")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var text string
+
+	switch wizard.step {
+	case stepCategories:
+		text = "Step 1/4: Tap the categories you want. Tap again to remove one."
+		var row []tgbotapi.InlineKeyboardButton
+		for _, category := range alertCategories {
+			label := capitalize(category)
+			if wizard.categories[category] {
+				label = "✅ " + label
+			}
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, wizardPrefix+"cat:"+category))
+			if len(row) == 2 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(cancel, next))
+	case stepKeywords:
+		text = "Step 2/4: Reply with keywords separated by commas (e.g. bitcoin, etf), or tap Skip."
+		if len(wizard.keywords) > 0 {
+			text += "\n\nKeywords so far: " + html.EscapeString(strings.Join(wizard.keywords, ", "))
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🗑 Clear keywords", wizardPrefix+"clear")))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(back, tgbotapi.NewInlineKeyboardButtonData("Skip ➡️", wizardPrefix+"next")))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(cancel))
+	case stepConfidence:
+		text = "Step 3/4: Only alert when the AI is at least this confident about the category."
+		var row []tgbotapi.InlineKeyboardButton
+		for _, confidence := range wizardConfidences {
+			label := "Any"
+			if confidence > 0 {
+				label = fmt.Sprintf("%.0f%%", confidence*100)
+			}
+			if confidence == wizard.minConfidence {
+				label = "✅ " + label
+			}
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%sconf:%g", wizardPrefix, confidence)))
+		}
+		rows = append(rows, row, tgbotapi.NewInlineKeyboardRow(back, cancel))
+	case stepConfirm:
+		text = "Step 4/4: Save this alert?\n\n" +
+			"Categories: " + formatList(selectedCategories(wizard)) + "\n" +
+			"Keywords: " + formatList(wizard.keywords) + "\n" +
+			"Minimum confidence: " + formatConfidence(wizard.minConfidence)
+		if wizard.replace {
+			text += "\n\nThis replaces your default alert."
+		}
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✅ Save", wizardPrefix+"save")),
+			tgbotapi.NewInlineKeyboardRow(back, cancel))
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return header + text, &markup
+}
+
+func selectedCategories(wizard *alertWizard) []string {
+	var categories []string
+	for _, category := range alertCategories {
+		if wizard.categories[category] {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+func isAlertCategory(value string) bool {
+	for _, category := range alertCategories {
+		if category == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatConfidence(confidence float64) string {
+	if confidence == 0 {
+		return "any"
+	}
+	return fmt.Sprintf("%.0f%%", confidence*100)
+}
@@ -0,0 +1,160 @@
+package telegram
+
+import (
+	"context"
+	"html"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ObiAU/hfnewsaggregator/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParseAlertOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		check   func(*models.UserAlert) bool
+		wantErr string
+	}{
+		{"lists", []string{"category=Finance, crypto", "sources=reuters", "sentiment=positive,neutral"}, func(a *models.UserAlert) bool {
+			return reflect.DeepEqual(a.Categories, []string{"finance", "crypto"}) && len(a.Sources) == 1 && len(a.Sentiments) == 2
+		}, ""},
+		{"confidence percent", []string{"min_confidence=80%"}, func(a *models.UserAlert) bool { return a.MinConfidence == 0.8 }, ""},
+		{"daily default time", []string{"delivery=daily"}, func(a *models.UserAlert) bool { return a.DigestAt == defaultDigestAt }, ""},
+		{"max age", []string{"max_age=2h"}, func(a *models.UserAlert) bool { return a.MaxAge == 2*time.Hour }, ""},
+		{"keywords and tags are cleaned", []string{"keywords=bitcoin, ,ETF ,", "tags=ai,machine learning,"}, func(a *models.UserAlert) bool {
+			return reflect.DeepEqual(a.Keywords, []string{"bitcoin", "ETF"}) && reflect.DeepEqual(a.Tags, []string{"ai", "machine learning"})
+		}, ""},
+		{"empty keywords", []string{"keywords=,"}, func(a *models.UserAlert) bool { return len(a.Keywords) == 0 }, ""},
+		{"missing equals", []string{"bitcoin"}, nil, "expected key=value"},
+		{"unknown option", []string{"colour=red"}, nil, `unknown option "colour"`},
+		{"bad sentiment", []string{"sentiment=angry"}, nil, "sentiment must be"},
+		{"bad confidence", []string{"min_confidence=150%"}, nil, "between 0 and 1"},
+		{"at without daily", []string{"at=08:00"}, nil, "only applies to delivery=daily"},
+		{"bad delivery", []string{"delivery=weekly"}, nil, "delivery must be"},
+		{"bad max age", []string{"max_age=-1h"}, nil, "max_age must be"},
+	}
+
+	for _, tt := range tests {
+		alert := &models.UserAlert{}
+		err := parseAlertOptions(tt.args, alert)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case !tt.check(alert):
+			t.Errorf("%s: unexpected alert %+v", tt.name, alert)
+		}
+	}
+}
+
+func TestWizardOptionsParse(t *testing.T) {
+	wizard := &alertWizard{
+		categories:    map[string]bool{"finance": true, "cryptocurrency": true, "sports": false},
+		keywords:      []string{"bitcoin", "etf"},
+		minConfidence: 0.7,
+	}
+
+	alert := &models.UserAlert{}
+	if err := parseAlertOptions(wizardOptions(wizard), alert); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(alert.Categories, []string{"cryptocurrency", "finance"}) {
+		t.Errorf("categories = %v", alert.Categories)
+	}
+	if !reflect.DeepEqual(alert.Keywords, []string{"bitcoin", "etf"}) || alert.MinConfidence != 0.7 {
+		t.Errorf("keywords = %v, confidence = %v", alert.Keywords, alert.MinConfidence)
+	}
+
+	if args := wizardOptions(&alertWizard{categories: map[string]bool{}}); len(args) != 0 {
+		t.Errorf("empty wizard produced %v", args)
+	}
+}
+
+func TestWizardSteps(t *testing.T) {
+	bot, _ := newTestBot(t)
+	ctx := context.Background()
+	bot.handleAlertWizard(1, 10, "")
+
+	press := func(data string) {
+		bot.handleWizardCallback(ctx, &tgbotapi.CallbackQuery{
+			ID:      "q",
+			From:    &tgbotapi.User{ID: 1},
+			Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: 10}},
+			Data:    data,
+		})
+	}
+
+	press(wizardPrefix + "cat:finance")
+	press(wizardPrefix + "cat:not-a-category")
+	press(wizardPrefix + "next")
+	if bot.handleWizardReply(1, 11, "bitcoin") {
+		t.Error("a reply from another chat was taken by the wizard")
+	}
+	if !bot.handleWizardReply(1, 10, "Bitcoin, ETF") {
+		t.Fatal("keyword reply was not taken by the wizard")
+	}
+	press(wizardPrefix + "conf:0.9")
+
+	bot.mu.Lock()
+	wizard := bot.wizards[1]
+	bot.mu.Unlock()
+	if wizard.step != stepConfirm || wizard.minConfidence != 0.9 || !wizard.replace {
+		t.Errorf("step = %d, confidence = %v, replace = %v", wizard.step, wizard.minConfidence, wizard.replace)
+	}
+	if !reflect.DeepEqual(selectedCategories(wizard), []string{"finance"}) || !reflect.DeepEqual(wizard.keywords, []string{"bitcoin", "etf"}) {
+		t.Errorf("categories = %v, keywords = %v", selectedCategories(wizard), wizard.keywords)
+	}
+
+	press(wizardPrefix + "cancel")
+	bot.mu.Lock()
+	_, ok := bot.wizards[1]
+	bot.mu.Unlock()
+	if ok {
+		t.Error("cancel did not discard the wizard")
+	}
+}
+
+func TestWizardExpires(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.wizards[1] = &alertWizard{chatID: 10, step: stepKeywords, updatedAt: time.Now().Add(-wizardTimeout - time.Minute)}
+
+	if bot.handleWizardReply(1, 10, "bitcoin") {
+		t.Error("an expired wizard took the reply")
+	}
+	if _, ok := bot.wizards[1]; ok {
+		t.Error("expired wizard was not discarded")
+	}
+}
+
+func TestHelpExamplesParse(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.handleStart(1)
+	bot.handleHelp(2)
+
+	var examples int
+	for _, chatID := range []int64{1, 2} {
+		for _, text := range queuedTexts(bot, chatID) {
+			for _, line := range strings.Split(text, "\n") {
+				_, example, ok := strings.Cut(html.UnescapeString(line), "/alert add ")
+				if !ok || strings.Contains(example, "[") {
+					continue
+				}
+				examples++
+				args := splitArgs(example)
+				if err := parseAlertOptions(args[1:], &models.UserAlert{}); err != nil {
+					t.Errorf("help example %q: %v", line, err)
+				}
+			}
+		}
+	}
+	if examples == 0 {
+		t.Fatal("no /alert add examples found in the help text")
+	}
+}
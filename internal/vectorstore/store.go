@@ -0,0 +1,155 @@
+package vectorstore
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	vector  []float64
+	addedAt time.Time
+}
+
+type Match struct {
+	Hash       string
+	Similarity float64
+}
+
+type Store struct {
+	mu            sync.RWMutex
+	vectors       map[string]entry
+	retention     time.Duration
+	cleanupTicker *time.Ticker
+	stopChan      chan struct{}
+}
+
+func New(retention time.Duration) *Store {
+	s := &Store{
+		vectors:   make(map[string]entry),
+		retention: retention,
+		stopChan:  make(chan struct{}),
+	}
+
+	s.cleanupTicker = time.NewTicker(1 * time.Hour)
+	go s.cleanup()
+
+	return s
+}
+
+func (s *Store) Add(hash string, vector []float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.vectors[hash] = entry{vector: normalize(vector), addedAt: time.Now()}
+}
+
+func (s *Store) Get(hash string) ([]float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, exists := s.vectors[hash]
+	return e.vector, exists
+}
+
+// Similarity returns the cosine similarity between the stored article vector
+// and query. The second result is false when the article has no embedding.
+func (s *Store) Similarity(hash string, query []float64) (float64, bool) {
+	vector, exists := s.Get(hash)
+	if !exists {
+		return 0, false
+	}
+	return Cosine(vector, query), true
+}
+
+func (s *Store) Search(query []float64, limit int) []Match {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]Match, 0, len(s.vectors))
+	for hash, e := range s.vectors {
+		matches = append(matches, Match{Hash: hash, Similarity: Cosine(e.vector, query)})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
+
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.vectors)
+}
+
+func (s *Store) cleanup() {
+	for {
+		select {
+		case <-s.cleanupTicker.C:
+			s.performCleanup()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+func (s *Store) performCleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.retention)
+
+	for hash, e := range s.vectors {
+		if e.addedAt.Before(cutoff) {
+			delete(s.vectors, hash)
+		}
+	}
+}
+
+func (s *Store) Close() {
+	s.cleanupTicker.Stop()
+	close(s.stopChan)
+}
+
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func normalize(vector []float64) []float64 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return vector
+	}
+
+	norm = math.Sqrt(norm)
+	out := make([]float64, len(vector))
+	for i, v := range vector {
+		out[i] = v / norm
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package vectorstore
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"identical", []float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{"scaled", []float64{1, 2, 3}, []float64{2, 4, 6}, 1},
+		{"orthogonal", []float64{1, 0}, []float64{0, 1}, 0},
+		{"opposite", []float64{1, 1}, []float64{-1, -1}, -1},
+		{"length mismatch", []float64{1, 2}, []float64{1, 2, 3}, 0},
+		{"empty", nil, nil, 0},
+		{"zero vector", []float64{0, 0}, []float64{1, 1}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: Cosine = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearchAndSimilarity(t *testing.T) {
+	s := New(time.Hour)
+	defer s.Close()
+
+	s.Add("north", []float64{0, 10})
+	s.Add("east", []float64{3, 0})
+	s.Add("northeast", []float64{1, 1})
+
+	matches := s.Search([]float64{0, 1}, 2)
+	if len(matches) != 2 || matches[0].Hash != "north" || matches[1].Hash != "northeast" {
+		t.Fatalf("Search = %+v, want north then northeast", matches)
+	}
+
+	if vector, _ := s.Get("north"); math.Abs(vector[1]-1) > 1e-9 {
+		t.Errorf("stored vector not normalized: %v", vector)
+	}
+
+	if _, ok := s.Similarity("missing", []float64{0, 1}); ok {
+		t.Error("Similarity reported a match for an article with no embedding")
+	}
+	if sim, ok := s.Similarity("east", []float64{1, 0}); !ok || math.Abs(sim-1) > 1e-9 {
+		t.Errorf("Similarity(east) = %v, %v; want 1, true", sim, ok)
+	}
+}
+
+func TestCleanupDropsExpiredVectors(t *testing.T) {
+	s := New(time.Minute)
+	defer s.Close()
+
+	s.Add("fresh", []float64{1})
+	s.Add("stale", []float64{1})
+	s.mu.Lock()
+	e := s.vectors["stale"]
+	e.addedAt = time.Now().Add(-2 * time.Minute)
+	s.vectors["stale"] = e
+	s.mu.Unlock()
+
+	s.performCleanup()
+
+	if _, ok := s.Get("stale"); ok {
+		t.Error("expired vector was kept")
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len = %d, want 1", s.Len())
+	}
+}
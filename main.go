@@ -22,7 +22,7 @@ func main() {
 	cacheLayer := cache.New(24 * time.Hour)
 	defer cacheLayer.Close()
 
-	telegramBot := telegram.NewBot(cfg.TelegramToken, cfg.TelegramWebhookURL)
+	telegramBot := telegram.NewBot(cfg.TelegramToken, cfg.TelegramWebhookURL, cfg.TelegramParseMode)
 
 	newsAggregator := aggregator.New(cfg, cacheLayer, telegramBot)
 